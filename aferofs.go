@@ -0,0 +1,55 @@
+package afero
+
+import (
+	"os"
+)
+
+// Afero wraps an Fs and exposes the package-level convenience helpers
+// (ReadFile, WriteFile, Exists, ...) as methods, so that calling code can
+// pass a single Afero value around instead of both an Fs and a package
+// reference.
+type Afero struct {
+	Fs
+}
+
+func (a Afero) ReadDir(dirname string) ([]os.FileInfo, error) {
+	return ReadDir(a.Fs, dirname)
+}
+
+func (a Afero) ReadFile(filename string) ([]byte, error) {
+	return ReadFile(a.Fs, filename)
+}
+
+func (a Afero) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	return WriteFile(a.Fs, filename, data, perm)
+}
+
+func (a Afero) Exists(path string) (bool, error) {
+	return Exists(a.Fs, path)
+}
+
+func (a Afero) DirExists(path string) (bool, error) {
+	return DirExists(a.Fs, path)
+}
+
+func (a Afero) IsDir(path string) (bool, error) {
+	return IsDir(a.Fs, path)
+}
+
+func (a Afero) IsEmpty(path string) (bool, error) {
+	return IsEmpty(a.Fs, path)
+}
+
+func (a Afero) TempFile(dir, pattern string) (File, error) {
+	return TempFile(a.Fs, dir, pattern)
+}
+
+func (a Afero) TempDir(dir, prefix string) (string, error) {
+	return TempDir(a.Fs, dir, prefix)
+}
+
+// LstatIfPossible calls LstatIfPossible on the wrapped Fs if it
+// implements Lstater, falling back to Stat (reporting false) otherwise.
+func (a Afero) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	return lstatIfPossible(a.Fs, name)
+}
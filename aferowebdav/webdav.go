@@ -0,0 +1,73 @@
+// Package aferowebdav adapts an afero.Fs to golang.org/x/net/webdav.FileSystem,
+// so that any Fs implementation (in-memory, base-path-restricted, ...) can be
+// served over WebDAV.
+package aferowebdav
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/getporter/afero"
+)
+
+// FileSystem adapts an afero.Fs to webdav.FileSystem. WebDAV paths are
+// always slash-separated and rooted at "/"; FileSystem cleans each path
+// and converts it to the target Fs's native separator before delegating.
+type FileSystem struct {
+	Fs afero.Fs
+}
+
+// New returns a webdav.FileSystem backed by fs.
+func New(fs afero.Fs) *FileSystem {
+	return &FileSystem{Fs: fs}
+}
+
+// fsPath cleans a WebDAV path and converts it to fs's native separator.
+func (f *FileSystem) fsPath(name string) string {
+	return filepath.FromSlash(path.Clean("/" + name))
+}
+
+func (f *FileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.Fs.Mkdir(f.fsPath(name), perm)
+}
+
+func (f *FileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	file, err := f.Fs.OpenFile(f.fsPath(name), flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	// afero.File already implements webdav.File's method set (Close,
+	// Read, Seek, Readdir, Stat, Write); no wrapping needed.
+	return file, nil
+}
+
+func (f *FileSystem) RemoveAll(ctx context.Context, name string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.Fs.RemoveAll(f.fsPath(name))
+}
+
+func (f *FileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return f.Fs.Rename(f.fsPath(oldName), f.fsPath(newName))
+}
+
+func (f *FileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return f.Fs.Stat(f.fsPath(name))
+}
@@ -0,0 +1,138 @@
+package aferowebdav
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getporter/afero"
+)
+
+const (
+	rootFile = "root.txt"
+	subDir   = "sub"
+	subFile  = "sub.txt"
+)
+
+// createTree and verifyTree mirror the helpers in afero's own
+// memmap_test.go (TestMemFsRenameDir/TestMemFsRemoveAll), but drive the
+// tree through the webdav.FileSystem adapter instead of the Fs directly.
+func createTree(ctx context.Context, fs *FileSystem, rootDir string) error {
+	if err := fs.Mkdir(ctx, rootDir, 0777); err != nil {
+		return fmt.Errorf("mkdir rootDir: %s", err)
+	}
+	if err := fs.Mkdir(ctx, filepath.Join(rootDir, subDir), 0777); err != nil {
+		return fmt.Errorf("mkdir subDir: %s", err)
+	}
+
+	rf, err := fs.OpenFile(ctx, filepath.Join(rootDir, rootFile), os.O_CREATE, 0666)
+	if err != nil {
+		return fmt.Errorf("create rootFile: %s", err)
+	}
+	rf.Close()
+
+	sf, err := fs.OpenFile(ctx, filepath.Join(rootDir, subDir, subFile), os.O_CREATE, 0666)
+	if err != nil {
+		return fmt.Errorf("create subFile: %s", err)
+	}
+	sf.Close()
+
+	return nil
+}
+
+func verifyTree(ctx context.Context, fs *FileSystem, rootDir string, exists bool) error {
+	verifyPath := func(p string) error {
+		_, err := fs.Stat(ctx, p)
+		if os.IsNotExist(err) == exists {
+			if exists {
+				return fmt.Errorf("%s was not created", p)
+			}
+			return fmt.Errorf("%s still exists", p)
+		}
+		return nil
+	}
+
+	if err := verifyPath(filepath.Join(rootDir, subDir)); err != nil {
+		return err
+	}
+	if err := verifyPath(filepath.Join(rootDir, subDir, subFile)); err != nil {
+		return err
+	}
+	if err := verifyPath(rootDir); err != nil {
+		return err
+	}
+	return verifyPath(filepath.Join(rootDir, rootFile))
+}
+
+func TestWebdavRenameDir(t *testing.T) {
+	ctx := context.Background()
+	fs := New(afero.NewMemMapFs())
+
+	const src = "/src"
+	const dst = "/dst"
+	const sibling = "/srcy"
+
+	if err := fs.Mkdir(ctx, sibling, 0777); err != nil {
+		t.Fatalf("Mkdir sibling failed: %s", err)
+	}
+	if err := createTree(ctx, fs, src); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyTree(ctx, fs, src, true); err != nil {
+		t.Fatalf("could not create source tree: %s", err)
+	}
+
+	if err := fs.Rename(ctx, src, dst); err != nil {
+		t.Fatalf("Rename failed: %s", err)
+	}
+
+	if err := verifyTree(ctx, fs, dst, true); err != nil {
+		t.Fatalf("renamed tree missing: %s", err)
+	}
+	if err := verifyTree(ctx, fs, src, false); err != nil {
+		t.Fatalf("original tree still present: %s", err)
+	}
+	if _, err := fs.Stat(ctx, sibling); err != nil {
+		t.Fatal("sibling directory should not have been affected")
+	}
+}
+
+func TestWebdavRemoveAll(t *testing.T) {
+	ctx := context.Background()
+	fs := New(afero.NewMemMapFs())
+
+	const root = "/root"
+	const sibling = "/rooty"
+
+	if err := fs.Mkdir(ctx, sibling, 0777); err != nil {
+		t.Fatalf("Mkdir sibling failed: %s", err)
+	}
+	if err := createTree(ctx, fs, root); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyTree(ctx, fs, root, true); err != nil {
+		t.Fatalf("could not create tree: %s", err)
+	}
+
+	if err := fs.RemoveAll(ctx, root); err != nil {
+		t.Fatalf("RemoveAll failed: %s", err)
+	}
+	if err := verifyTree(ctx, fs, root, false); err != nil {
+		t.Fatalf("tree was not removed: %s", err)
+	}
+	if _, err := fs.Stat(ctx, sibling); err != nil {
+		t.Fatal("sibling directory should not have been removed")
+	}
+}
+
+func TestWebdavCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	fs := New(afero.NewMemMapFs())
+	if err := fs.Mkdir(ctx, "/anything", 0777); err == nil {
+		t.Error("Mkdir with a canceled context should fail")
+	}
+}
@@ -0,0 +1,241 @@
+package afero
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// BasePathFs restricts all operations to a given path within an Fs. The
+// given file name to the operations on this Fs will be prepended with
+// the base path before calling the base Fs.
+//
+// Any file name (after filepath.Clean) outside this base path will be
+// treated as non-existent (os.ErrNotExist). Errors that BasePathFs builds
+// itself carry the caller's path, not the base path; errors returned by
+// the source Fs are passed through unchanged and may contain the full
+// path, base path included.
+type BasePathFs struct {
+	source Fs
+	path   string
+}
+
+type BasePathFile struct {
+	File
+	path string
+}
+
+func (f *BasePathFile) Name() string {
+	sourcename := f.File.Name()
+	return strings.TrimPrefix(sourcename, filepath.Clean(f.path))
+}
+
+func NewBasePathFs(source Fs, path string) Fs {
+	return &BasePathFs{source: source, path: path}
+}
+
+// RealPath returns the full path to the file relative to the base
+// filesystem's root, or os.ErrNotExist if the given path escapes the
+// base path (on Windows, a name with a volume is rejected with an
+// *os.PathError).
+func (b *BasePathFs) RealPath(name string) (path string, err error) {
+	if err := validateBasePathName(name); err != nil {
+		return name, err
+	}
+
+	bpath := filepath.Clean(b.path)
+	path = filepath.Clean(filepath.Join(bpath, name))
+	if !isWithinBase(path, bpath) {
+		return name, os.ErrNotExist
+	}
+
+	return path, nil
+}
+
+// isWithinBase reports whether path is bpath itself or a descendant of
+// it. A bare strings.HasPrefix(path, bpath) is not enough: it also
+// matches a sibling directory that merely shares bpath as a string
+// prefix, e.g. bpath "/sandbox" and path "/sandbox-evil/secret".
+func isWithinBase(path, bpath string) bool {
+	if path == bpath {
+		return true
+	}
+	prefix := bpath
+	if !strings.HasSuffix(prefix, FilePathSeparator) {
+		prefix += FilePathSeparator
+	}
+	return strings.HasPrefix(path, prefix)
+}
+
+func validateBasePathName(name string) error {
+	if runtime.GOOS != "windows" {
+		return nil
+	}
+	// Windows drive letters like `C:\` are not supported when joined
+	// against a base path; reject them up front rather than silently
+	// producing a bogus path.
+	volName := filepath.VolumeName(name)
+	if volName != "" {
+		return &os.PathError{Op: "realPath", Path: name, Err: os.ErrNotExist}
+	}
+	return nil
+}
+
+func (b *BasePathFs) Chtimes(name string, atime, mtime time.Time) (err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return &os.PathError{Op: "chtimes", Path: name, Err: err}
+	}
+	return b.source.Chtimes(name, atime, mtime)
+}
+
+func (b *BasePathFs) Chmod(name string, mode os.FileMode) (err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: err}
+	}
+	return b.source.Chmod(name, mode)
+}
+
+func (b *BasePathFs) Chown(name string, uid, gid int) (err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return &os.PathError{Op: "chown", Path: name, Err: err}
+	}
+	return b.source.Chown(name, uid, gid)
+}
+
+func (b *BasePathFs) Name() string {
+	return "BasePathFs"
+}
+
+func (b *BasePathFs) Stat(name string) (fi os.FileInfo, err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return b.source.Stat(name)
+}
+
+// LstatIfPossible delegates to the source's Lstat when it implements
+// Lstater, translating the path the same way Stat does.
+func (b *BasePathFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	name, err := b.RealPath(name)
+	if err != nil {
+		return nil, false, &os.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	if lstater, ok := b.source.(Lstater); ok {
+		return lstater.LstatIfPossible(name)
+	}
+	fi, err := b.source.Stat(name)
+	return fi, false, err
+}
+
+// Symlink creates newname, within the base path, as a symbolic link to
+// oldname. oldname is passed through to the source filesystem unmodified
+// (so that a relative target still resolves relative to newname once
+// both are rooted at the same base path), but it is first rejected if it
+// would resolve outside the base path -- otherwise a caller could use an
+// absolute or ".."-laden oldname to make a later Open of the link escape
+// the sandbox BasePathFs promises.
+func (b *BasePathFs) Symlink(oldname, newname string) (err error) {
+	symlinker, ok := b.source.(Symlinker)
+	if !ok {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: ErrNoSymlink}
+	}
+	realNewname, err := b.RealPath(newname)
+	if err != nil {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: err}
+	}
+
+	target := oldname
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(realNewname), target)
+	}
+	if bpath := filepath.Clean(b.path); !isWithinBase(filepath.Clean(target), bpath) {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: os.ErrNotExist}
+	}
+
+	return symlinker.Symlink(oldname, realNewname)
+}
+
+func (b *BasePathFs) Readlink(name string) (string, error) {
+	linkReader, ok := b.source.(LinkReader)
+	if !ok {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: ErrNoReadlink}
+	}
+	name, err := b.RealPath(name)
+	if err != nil {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return linkReader.Readlink(name)
+}
+
+func (b *BasePathFs) Rename(oldname, newname string) (err error) {
+	if oldname, err = b.RealPath(oldname); err != nil {
+		return &os.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+	if newname, err = b.RealPath(newname); err != nil {
+		return &os.PathError{Op: "rename", Path: newname, Err: err}
+	}
+	return b.source.Rename(oldname, newname)
+}
+
+func (b *BasePathFs) RemoveAll(name string) (err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return &os.PathError{Op: "remove_all", Path: name, Err: err}
+	}
+	return b.source.RemoveAll(name)
+}
+
+func (b *BasePathFs) Remove(name string) (err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: err}
+	}
+	return b.source.Remove(name)
+}
+
+func (b *BasePathFs) OpenFile(name string, flag int, mode os.FileMode) (f File, err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return nil, &os.PathError{Op: "openfile", Path: name, Err: err}
+	}
+	sourcef, err := b.source.OpenFile(name, flag, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &BasePathFile{sourcef, b.path}, nil
+}
+
+func (b *BasePathFs) Open(name string) (f File, err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	sourcef, err := b.source.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &BasePathFile{sourcef, b.path}, nil
+}
+
+func (b *BasePathFs) Mkdir(name string, mode os.FileMode) (err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	return b.source.Mkdir(name, mode)
+}
+
+func (b *BasePathFs) MkdirAll(name string, mode os.FileMode) (err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	return b.source.MkdirAll(name, mode)
+}
+
+func (b *BasePathFs) Create(name string) (f File, err error) {
+	if name, err = b.RealPath(name); err != nil {
+		return nil, &os.PathError{Op: "create", Path: name, Err: err}
+	}
+	sourcef, err := b.source.Create(name)
+	if err != nil {
+		return nil, err
+	}
+	return &BasePathFile{sourcef, b.path}, nil
+}
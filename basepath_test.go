@@ -0,0 +1,92 @@
+package afero
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBasePathFsSymlinkRejectsEscapingTarget(t *testing.T) {
+	base := NewMemMapFs()
+	if err := WriteFile(base, "/secret.txt", []byte("top secret"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := base.MkdirAll("/sandbox", 0777); err != nil {
+		t.Fatal(err)
+	}
+	bfs := NewBasePathFs(base, "/sandbox")
+
+	if err := bfs.(Symlinker).Symlink("/secret.txt", "leak"); err == nil {
+		t.Fatal("Symlink with an absolute oldname outside the base path should have failed")
+	}
+	if err := bfs.(Symlinker).Symlink("../secret.txt", "leak2"); err == nil {
+		t.Fatal("Symlink with a relative oldname escaping the base path should have failed")
+	}
+
+	if _, err := bfs.Stat("leak"); !os.IsNotExist(err) {
+		t.Fatalf("rejected symlink must not have been created, Stat = %v", err)
+	}
+}
+
+// TestBasePathFsSymlinkRejectsSiblingDirectory guards against a
+// string-prefix check mistaking a sibling directory that merely shares
+// the base path as a text prefix (e.g. "/sandbox-evil") for a path
+// contained within "/sandbox".
+func TestBasePathFsSymlinkRejectsSiblingDirectory(t *testing.T) {
+	base := NewMemMapFs()
+	if err := base.MkdirAll("/sandbox-evil", 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(base, "/sandbox-evil/secretdata.txt", []byte("top secret"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := base.MkdirAll("/sandbox", 0777); err != nil {
+		t.Fatal(err)
+	}
+	bfs := NewBasePathFs(base, "/sandbox")
+
+	if err := bfs.(Symlinker).Symlink("../sandbox-evil/secretdata.txt", "leak"); err == nil {
+		t.Fatal("Symlink targeting a sibling directory of the base path should have failed")
+	}
+	if _, err := bfs.Stat("leak"); !os.IsNotExist(err) {
+		t.Fatalf("rejected symlink must not have been created, Stat = %v", err)
+	}
+}
+
+// TestBasePathFsRealPathRejectsSiblingDirectory is the same boundary
+// check directly against RealPath, used by every other BasePathFs
+// method.
+func TestBasePathFsRealPathRejectsSiblingDirectory(t *testing.T) {
+	base := NewMemMapFs()
+	if err := base.MkdirAll("/sandbox", 0777); err != nil {
+		t.Fatal(err)
+	}
+	bfs := NewBasePathFs(base, "/sandbox").(*BasePathFs)
+
+	if _, err := bfs.RealPath("../sandbox-evil/secretdata.txt"); err == nil {
+		t.Fatal("RealPath should reject a path resolving into a sibling directory")
+	}
+}
+
+func TestBasePathFsSymlinkAllowsContainedTarget(t *testing.T) {
+	base := NewMemMapFs()
+	if err := base.MkdirAll("/sandbox/dir", 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(base, "/sandbox/dir/real.txt", []byte("hello"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	bfs := NewBasePathFs(base, "/sandbox")
+
+	if err := bfs.(Symlinker).Symlink("real.txt", filepath.Join("dir", "link.txt")); err != nil {
+		t.Fatalf("Symlink within the base path should succeed: %s", err)
+	}
+
+	got, err := ReadFile(bfs, filepath.Join("dir", "link.txt"))
+	if err != nil {
+		t.Fatalf("reading through the symlink: %s", err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("read %q through symlink, want %q", got, "hello")
+	}
+}
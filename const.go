@@ -0,0 +1,10 @@
+package afero
+
+import "os"
+
+const (
+	// FilePathSeparator is the OS-specific path separator, as a string,
+	// suitable for use in the in-memory filesystems which key their
+	// entries by slash-cleaned path.
+	FilePathSeparator = string(os.PathSeparator)
+)
@@ -0,0 +1,463 @@
+package afero
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CopyOnWriteFs is a read-only base Fs overlaid with a writable layer.
+// All reads are satisfied by the layer if present there, otherwise the
+// base; all writes, including copy-up of a base file opened for writing,
+// go to the layer only. The base is never modified.
+type CopyOnWriteFs struct {
+	base  Fs
+	layer Fs
+
+	// whiteoutDir, when non-empty (set via WithWhiteouts), is a
+	// directory in layer under which a zero-byte marker file is created
+	// for every path deleted by Remove/RemoveAll that still exists in
+	// base, so it stays hidden across remounts of the same layer.
+	whiteoutDir string
+}
+
+func NewCopyOnWriteFs(base Fs, layer Fs) Fs {
+	return &CopyOnWriteFs{base: base, layer: layer}
+}
+
+// WithWhiteouts returns a CopyOnWriteFs that records tombstones for files
+// deleted from the base layer as zero-byte marker files under dirName in
+// the writable layer, so that ReadDir/Stat/Open keep hiding them even
+// after the process restarts and remounts the same writable layer.
+func WithWhiteouts(base, layer Fs, dirName string) Fs {
+	return &CopyOnWriteFs{base: base, layer: layer, whiteoutDir: dirName}
+}
+
+func (u *CopyOnWriteFs) Name() string {
+	return "CopyOnWriteFs"
+}
+
+// whiteoutEncode and whiteoutDecode map a cleaned path to/from a single
+// flat marker filename living directly under whiteoutDir. Markers are
+// never nested to mirror the path's own directory structure: doing so
+// would make a marker for "dir/gone.txt" create a scaffold directory
+// "whiteoutDir/dir" that Stat-based lookups for the unrelated path "dir"
+// itself could mistake for a marker.
+func whiteoutEncode(name string) string {
+	name = strings.ReplaceAll(name, "%", "%25")
+	return strings.ReplaceAll(name, FilePathSeparator, "%2F")
+}
+
+func whiteoutDecode(encoded string) string {
+	decoded := strings.ReplaceAll(encoded, "%2F", FilePathSeparator)
+	return strings.ReplaceAll(decoded, "%25", "%")
+}
+
+func (u *CopyOnWriteFs) whiteoutPath(name string) string {
+	return filepath.Join(u.whiteoutDir, whiteoutEncode(filepath.Clean(name)))
+}
+
+// isWhiteout reports whether name, or any ancestor directory of name, was
+// recorded as deleted from the base layer -- a whiteout on a directory
+// (from a RemoveAll) hides its whole subtree, not just that one path. The
+// walk stops at "." / the path separator without testing them: those are
+// the synthetic root, not a whiteout-able path, and whiteoutDir itself
+// lives there once any marker has ever been recorded.
+func (u *CopyOnWriteFs) isWhiteout(name string) bool {
+	if u.whiteoutDir == "" {
+		return false
+	}
+	for p := filepath.Clean(name); p != "." && p != FilePathSeparator; p = filepath.Dir(p) {
+		if _, err := u.layer.Stat(u.whiteoutPath(p)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// listWhiteouts returns the base names of whiteout markers recorded
+// directly under dir, for ReadDir to filter out of a merged listing. It
+// also hides whiteoutDir itself from its own parent directory's listing,
+// since it's an implementation detail of the union, not a real entry.
+func (u *CopyOnWriteFs) listWhiteouts(dir string) map[string]bool {
+	whiteouts := map[string]bool{}
+	if u.whiteoutDir == "" {
+		return whiteouts
+	}
+	dir = filepath.Clean(dir)
+
+	cleanWhiteoutDir := filepath.Clean(u.whiteoutDir)
+	if filepath.Dir(cleanWhiteoutDir) == dir {
+		whiteouts[filepath.Base(cleanWhiteoutDir)] = true
+	}
+
+	entries, err := ReadDir(u.layer, u.whiteoutDir)
+	if err != nil {
+		return whiteouts
+	}
+	for _, fi := range entries {
+		name := whiteoutDecode(fi.Name())
+		if filepath.Dir(name) == dir {
+			whiteouts[filepath.Base(name)] = true
+		}
+	}
+	return whiteouts
+}
+
+func (u *CopyOnWriteFs) addWhiteout(name string) error {
+	if u.whiteoutDir == "" {
+		return nil
+	}
+	if err := u.layer.MkdirAll(u.whiteoutDir, 0777); err != nil {
+		return err
+	}
+	f, err := u.layer.Create(u.whiteoutPath(name))
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func (u *CopyOnWriteFs) removeWhiteout(name string) {
+	if u.whiteoutDir == "" {
+		return
+	}
+	u.layer.Remove(u.whiteoutPath(name))
+}
+
+func copyToLayer(base Fs, layer Fs, name string) error {
+	return copyFileToLayer(base, layer, name, os.O_RDWR, 0o777)
+}
+
+func copyFileToLayer(base Fs, layer Fs, name string, flag int, perm os.FileMode) error {
+	bfh, err := base.Open(name)
+	if err != nil {
+		return err
+	}
+	defer bfh.Close()
+
+	bfi, err := bfh.Stat()
+	if err != nil {
+		return err
+	}
+	if bfi.IsDir() {
+		return layer.MkdirAll(name, bfi.Mode())
+	}
+
+	if err := layer.MkdirAll(filepath.Dir(name), 0o777); err != nil {
+		return err
+	}
+
+	lfh, err := layer.OpenFile(name, os.O_CREATE|flag, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := copyFile(lfh, bfh); err != nil {
+		lfh.Close()
+		return err
+	}
+	if err := lfh.Close(); err != nil {
+		return err
+	}
+	return layer.Chtimes(name, bfi.ModTime(), bfi.ModTime())
+}
+
+func copyFile(dst, src File) (int64, error) {
+	buf := make([]byte, 32*1024)
+	var written int64
+	for {
+		nr, rerr := src.Read(buf)
+		if nr > 0 {
+			nw, werr := dst.Write(buf[:nr])
+			written += int64(nw)
+			if werr != nil {
+				return written, werr
+			}
+			if nw != nr {
+				return written, fmt.Errorf("short write")
+			}
+		}
+		if rerr != nil {
+			if rerr == io.EOF {
+				return written, nil
+			}
+			return written, rerr
+		}
+		if nr == 0 {
+			return written, nil
+		}
+	}
+}
+
+func (u *CopyOnWriteFs) isBaseFile(name string) (bool, error) {
+	if _, err := u.layer.Stat(name); err == nil {
+		return false, nil
+	}
+	_, err := u.base.Stat(name)
+	if err != nil {
+		if oerr, ok := err.(*os.PathError); ok {
+			if oerr.Err == os.ErrNotExist || os.IsNotExist(oerr.Err) {
+				return false, nil
+			}
+		}
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return true, err
+	}
+	return true, nil
+}
+
+func (u *CopyOnWriteFs) copyToLayer(name string) error {
+	return copyToLayer(u.base, u.layer, name)
+}
+
+func (u *CopyOnWriteFs) Chtimes(name string, atime, mtime time.Time) error {
+	if u.isWhiteout(name) {
+		return &os.PathError{Op: "chtimes", Path: name, Err: os.ErrNotExist}
+	}
+	b, err := u.isBaseFile(name)
+	if err != nil {
+		return err
+	}
+	if b {
+		if err := u.copyToLayer(name); err != nil {
+			return err
+		}
+	}
+	return u.layer.Chtimes(name, atime, mtime)
+}
+
+func (u *CopyOnWriteFs) Chmod(name string, mode os.FileMode) error {
+	if u.isWhiteout(name) {
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	b, err := u.isBaseFile(name)
+	if err != nil {
+		return err
+	}
+	if b {
+		if err := u.copyToLayer(name); err != nil {
+			return err
+		}
+	}
+	return u.layer.Chmod(name, mode)
+}
+
+func (u *CopyOnWriteFs) Chown(name string, uid, gid int) error {
+	if u.isWhiteout(name) {
+		return &os.PathError{Op: "chown", Path: name, Err: os.ErrNotExist}
+	}
+	b, err := u.isBaseFile(name)
+	if err != nil {
+		return err
+	}
+	if b {
+		if err := u.copyToLayer(name); err != nil {
+			return err
+		}
+	}
+	return u.layer.Chown(name, uid, gid)
+}
+
+func (u *CopyOnWriteFs) Stat(name string) (os.FileInfo, error) {
+	if u.isWhiteout(name) {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	fi, err := u.layer.Stat(name)
+	if err != nil {
+		if e, ok := err.(*os.PathError); ok && (e.Err == os.ErrNotExist || os.IsNotExist(e.Err)) {
+			return u.base.Stat(name)
+		}
+		if os.IsNotExist(err) {
+			return u.base.Stat(name)
+		}
+		return nil, err
+	}
+	return fi, nil
+}
+
+// LstatIfPossible reports the layer's entry (without following a
+// trailing symlink there) if present, otherwise the base's.
+func (u *CopyOnWriteFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	if u.isWhiteout(name) {
+		return nil, false, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+	}
+	fi, lstat, err := lstatIfPossible(u.layer, name)
+	if err == nil {
+		return fi, lstat, nil
+	}
+	return lstatIfPossible(u.base, name)
+}
+
+func (u *CopyOnWriteFs) Rename(o, n string) error {
+	if u.isWhiteout(o) {
+		return &os.PathError{Op: "rename", Path: o, Err: os.ErrNotExist}
+	}
+	b, err := u.isBaseFile(o)
+	if err != nil {
+		return err
+	}
+	if b {
+		if err := u.copyToLayer(o); err != nil {
+			return err
+		}
+		if err := u.addWhiteout(o); err != nil {
+			return err
+		}
+	}
+	return u.layer.Rename(o, n)
+}
+
+func (u *CopyOnWriteFs) Remove(name string) error {
+	if u.isWhiteout(name) {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	layerErr := u.layer.Remove(name)
+	if layerErr != nil && !os.IsNotExist(layerErr) {
+		return layerErr
+	}
+	if _, err := u.base.Stat(name); err == nil {
+		return u.addWhiteout(name)
+	}
+	if layerErr != nil {
+		return layerErr
+	}
+	return nil
+}
+
+func (u *CopyOnWriteFs) RemoveAll(name string) error {
+	if u.isWhiteout(name) {
+		return nil
+	}
+	layerErr := u.layer.RemoveAll(name)
+	if layerErr != nil && !os.IsNotExist(layerErr) {
+		return layerErr
+	}
+	if _, err := u.base.Stat(name); err == nil {
+		return u.addWhiteout(name)
+	}
+	return nil
+}
+
+func (u *CopyOnWriteFs) Open(name string) (File, error) {
+	if u.isWhiteout(name) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	ol, errl := u.layer.Open(name)
+	if errl == nil {
+		fi, err := ol.Stat()
+		if err == nil && fi.IsDir() {
+			if ob, errb := u.base.Open(name); errb == nil {
+				return &UnionFile{base: ob, layer: ol, whiteouts: u.listWhiteouts(name)}, nil
+			}
+		}
+		return ol, nil
+	}
+	if !os.IsNotExist(errl) {
+		return nil, errl
+	}
+	ob, errb := u.base.Open(name)
+	if errb != nil {
+		return nil, errb
+	}
+	// A directory that exists only in base can still have whiteouts
+	// recorded for entries directly beneath it; filter those out of its
+	// Readdir results the same way a layer+base merge would.
+	if fi, err := ob.Stat(); err == nil && fi.IsDir() {
+		if whiteouts := u.listWhiteouts(name); len(whiteouts) > 0 {
+			return &UnionFile{base: ob, whiteouts: whiteouts}, nil
+		}
+	}
+	return ob, nil
+}
+
+func (u *CopyOnWriteFs) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if u.isWhiteout(name) {
+		if flag&os.O_CREATE == 0 {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		u.removeWhiteout(name)
+		return u.layer.OpenFile(name, flag, perm)
+	}
+
+	writing := flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0
+	if !writing {
+		if _, err := u.layer.Stat(name); err == nil {
+			return u.layer.OpenFile(name, flag, perm)
+		}
+		return u.base.OpenFile(name, flag, perm)
+	}
+
+	b, err := u.isBaseFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if b && flag&os.O_TRUNC == 0 {
+		if err := u.copyToLayer(name); err != nil {
+			return nil, err
+		}
+	}
+	return u.layer.OpenFile(name, flag, perm)
+}
+
+// Symlink always writes to the layer, copying the base's directory up
+// first when needed so the new link has somewhere to live.
+func (u *CopyOnWriteFs) Symlink(oldname, newname string) error {
+	symlinker, ok := u.layer.(Symlinker)
+	if !ok {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: ErrNoSymlink}
+	}
+	u.removeWhiteout(newname)
+	dir := filepath.Dir(newname)
+	b, err := u.isBaseFile(dir)
+	if err != nil {
+		return err
+	}
+	if b {
+		if err := u.copyToLayer(dir); err != nil {
+			return err
+		}
+	}
+	return symlinker.Symlink(oldname, newname)
+}
+
+// Readlink checks the layer first, then falls back to the base.
+func (u *CopyOnWriteFs) Readlink(name string) (string, error) {
+	if u.isWhiteout(name) {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: os.ErrNotExist}
+	}
+	if lr, ok := u.layer.(LinkReader); ok {
+		if target, err := lr.Readlink(name); err == nil {
+			return target, nil
+		}
+	}
+	if lr, ok := u.base.(LinkReader); ok {
+		return lr.Readlink(name)
+	}
+	return "", &os.PathError{Op: "readlink", Path: name, Err: ErrNoReadlink}
+}
+
+func (u *CopyOnWriteFs) Mkdir(name string, perm os.FileMode) error {
+	if !u.isWhiteout(name) {
+		dir, err := IsDir(u.base, name)
+		if err == nil && dir {
+			return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrExist}
+		}
+	}
+	u.removeWhiteout(name)
+	return u.layer.MkdirAll(name, perm)
+}
+
+func (u *CopyOnWriteFs) MkdirAll(name string, perm os.FileMode) error {
+	u.removeWhiteout(name)
+	return u.layer.MkdirAll(name, perm)
+}
+
+func (u *CopyOnWriteFs) Create(name string) (File, error) {
+	u.removeWhiteout(name)
+	return u.layer.Create(name)
+}
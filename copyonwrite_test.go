@@ -0,0 +1,120 @@
+package afero
+
+import (
+	"os"
+	"testing"
+)
+
+// buildBaseTree creates a small tree directly on the base Fs: /dir/keep.txt
+// and /dir/gone.txt, plus a sibling /other.txt.
+func buildBaseTree(t *testing.T, base Fs) {
+	t.Helper()
+	if err := base.MkdirAll("dir", 0777); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(base, "dir/keep.txt", []byte("keep"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(base, "dir/gone.txt", []byte("gone"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFile(base, "other.txt", []byte("other"), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCopyOnWriteFsWhiteoutHidesBaseFile(t *testing.T) {
+	base := NewMemMapFs()
+	buildBaseTree(t, base)
+	layer := NewMemMapFs()
+
+	ufs := WithWhiteouts(base, layer, ".whiteouts")
+	if err := ufs.Remove("dir/gone.txt"); err != nil {
+		t.Fatalf("Remove: %s", err)
+	}
+
+	if _, err := ufs.Stat("dir/gone.txt"); !os.IsNotExist(err) {
+		t.Fatalf("Stat of removed base file = %v, want IsNotExist", err)
+	}
+	if _, err := ufs.Open("dir/gone.txt"); !os.IsNotExist(err) {
+		t.Fatalf("Open of removed base file = %v, want IsNotExist", err)
+	}
+
+	entries, err := ReadDir(ufs, "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fi := range entries {
+		if fi.Name() == "gone.txt" {
+			t.Fatalf("ReadDir still lists whited-out entry %q", fi.Name())
+		}
+	}
+
+	// dir/keep.txt and the sibling file must be unaffected.
+	if _, err := ufs.Stat("dir/keep.txt"); err != nil {
+		t.Fatalf("Stat of untouched base file: %s", err)
+	}
+	if _, err := ufs.Stat("other.txt"); err != nil {
+		t.Fatalf("Stat of sibling base file: %s", err)
+	}
+
+	// The base itself must be untouched.
+	if _, err := base.Stat("dir/gone.txt"); err != nil {
+		t.Fatalf("whiteout must not touch the base layer: %s", err)
+	}
+}
+
+func TestCopyOnWriteFsMkdirClearsWhiteout(t *testing.T) {
+	base := NewMemMapFs()
+	buildBaseTree(t, base)
+	layer := NewMemMapFs()
+
+	ufs := WithWhiteouts(base, layer, ".whiteouts")
+	if err := ufs.RemoveAll("dir"); err != nil {
+		t.Fatalf("RemoveAll: %s", err)
+	}
+	if _, err := ufs.Stat("dir"); !os.IsNotExist(err) {
+		t.Fatalf("Stat after RemoveAll = %v, want IsNotExist", err)
+	}
+
+	if err := ufs.MkdirAll("dir", 0777); err != nil {
+		t.Fatalf("MkdirAll: %s", err)
+	}
+	if _, err := ufs.Stat("dir"); err != nil {
+		t.Fatalf("Stat after recreating dir: %s", err)
+	}
+
+	entries, err := ReadDir(ufs, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fi := range entries {
+		if fi.Name() == ".whiteouts" {
+			t.Fatalf("ReadDir leaks the whiteout directory itself: %+v", entries)
+		}
+	}
+}
+
+func TestCopyOnWriteFsWhiteoutSurvivesRemount(t *testing.T) {
+	base := NewMemMapFs()
+	buildBaseTree(t, base)
+	layer := NewMemMapFs()
+
+	first := WithWhiteouts(base, layer, ".whiteouts")
+	if err := first.RemoveAll("dir"); err != nil {
+		t.Fatalf("RemoveAll: %s", err)
+	}
+
+	// Remount: a fresh CopyOnWriteFs over the same base and layer should
+	// still hide the deleted subtree, since the tombstone lives in layer.
+	second := WithWhiteouts(base, layer, ".whiteouts")
+	if _, err := second.Stat("dir"); !os.IsNotExist(err) {
+		t.Fatalf("Stat of removed dir after remount = %v, want IsNotExist", err)
+	}
+	if _, err := second.Stat("dir/keep.txt"); !os.IsNotExist(err) {
+		t.Fatalf("Stat of file under removed dir after remount = %v, want IsNotExist", err)
+	}
+	if _, err := second.Stat("other.txt"); err != nil {
+		t.Fatalf("Stat of sibling base file after remount: %s", err)
+	}
+}
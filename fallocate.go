@@ -0,0 +1,22 @@
+package afero
+
+// Flags accepted by Fallocator.Fallocate, matching the Linux
+// fallocate(2) mode bits of the same name.
+const (
+	FALLOC_FL_KEEP_SIZE  = 0x01
+	FALLOC_FL_PUNCH_HOLE = 0x02
+)
+
+// Fallocator is implemented by File implementations that can preallocate
+// or punch holes in their backing storage. Callers that need it should
+// type-assert a File returned by Open/OpenFile/Create to this interface,
+// the same way Lstater is used on an Fs.
+type Fallocator interface {
+	// Fallocate manipulates the allocated space for the range
+	// [offset, offset+length). With FALLOC_FL_PUNCH_HOLE it frees the
+	// backing storage for that range, without changing the file's
+	// logical size; subsequent reads of the range return zeros. Without
+	// FALLOC_FL_PUNCH_HOLE it ensures the file is at least
+	// offset+length bytes long, unless FALLOC_FL_KEEP_SIZE is also set.
+	Fallocate(offset, length int64, mode uint32) error
+}
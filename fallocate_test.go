@@ -0,0 +1,120 @@
+package afero
+
+import (
+	"io"
+	"testing"
+)
+
+// TestSparseFileLargeOffset writes a few bytes at a 1 GiB offset and
+// confirms the file's logical size reflects the write while the backing
+// extents stay proportional to the bytes actually written, not to the
+// logical size.
+func TestSparseFileLargeOffset(t *testing.T) {
+	fs := NewMemMapFs()
+	f, err := fs.Create("sparse.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	const offset = 1 << 30 // 1 GiB
+	payload := []byte("hello")
+
+	if _, err := f.WriteAt(payload, offset); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(offset + len(payload)); fi.Size() != want {
+		t.Errorf("Size() = %d, want %d", fi.Size(), want)
+	}
+
+	mf, ok := f.(interface{ AllocatedBytes() int64 })
+	if !ok {
+		t.Fatal("mem file does not expose AllocatedBytes")
+	}
+	if alloc := mf.AllocatedBytes(); alloc > int64(len(payload))*2 {
+		t.Errorf("AllocatedBytes() = %d, want proportional to %d bytes written", alloc, len(payload))
+	}
+
+	buf := make([]byte, len(payload))
+	if _, err := f.ReadAt(buf, offset); err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != string(payload) {
+		t.Errorf("ReadAt at offset = %q, want %q", buf, payload)
+	}
+
+	// Reading from within the hole before the payload must return zeros.
+	hole := make([]byte, 16)
+	if _, err := f.ReadAt(hole, offset-32); err != nil {
+		t.Fatal(err)
+	}
+	for i, b := range hole {
+		if b != 0 {
+			t.Fatalf("hole byte %d = %d, want 0", i, b)
+		}
+	}
+}
+
+// TestFallocatePunchHole writes data, punches a hole in the middle, and
+// confirms the hole reads back as zeros while the surrounding data and
+// the file's logical size are unaffected.
+func TestFallocatePunchHole(t *testing.T) {
+	fs := NewMemMapFs()
+	f, err := fs.Create("punch.bin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	falloc, ok := f.(Fallocator)
+	if !ok {
+		t.Fatal("mem file does not implement Fallocator")
+	}
+	if err := falloc.Fallocate(64, 64, FALLOC_FL_PUNCH_HOLE); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Size() != int64(len(data)) {
+		t.Errorf("Size() after punch = %d, want %d", fi.Size(), len(data))
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	readBack := make([]byte, len(data))
+	if _, err := io.ReadFull(f, readBack); err != nil {
+		t.Fatal(err)
+	}
+	for i := 64; i < 128; i++ {
+		if readBack[i] != 0 {
+			t.Errorf("byte %d = %d, want 0 (punched)", i, readBack[i])
+		}
+	}
+	for i := 0; i < 64; i++ {
+		if readBack[i] != data[i] {
+			t.Errorf("byte %d = %d, want %d (untouched)", i, readBack[i], data[i])
+		}
+	}
+	for i := 128; i < 256; i++ {
+		if readBack[i] != data[i] {
+			t.Errorf("byte %d = %d, want %d (untouched)", i, readBack[i], data[i])
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package afero
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	tempCounterMu sync.Mutex
+	tempCounter   uint32
+)
+
+func nextTempSuffix() uint32 {
+	tempCounterMu.Lock()
+	defer tempCounterMu.Unlock()
+	tempCounter++
+	return tempCounter
+}
+
+// TempFile creates a new temporary file in the directory dir, opens the file
+// for reading and writing, and returns the resulting File. It follows the
+// same naming convention as ioutil.TempFile.
+func TempFile(fs Fs, dir, pattern string) (f File, err error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	prefix, suffix := pattern, ""
+	if pos := lastIndexByte(pattern, '*'); pos != -1 {
+		prefix, suffix = pattern[:pos], pattern[pos+1:]
+	}
+
+	for i := 0; i < 10000; i++ {
+		name := filepath.Join(dir, prefix+fmt.Sprintf("%d", nextTempSuffix())+suffix)
+		f, err = fs.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			continue
+		}
+		break
+	}
+	return
+}
+
+// TempDir creates a new temporary directory in the directory dir with a name
+// beginning with prefix and returns the path of the new directory.
+func TempDir(fs Fs, dir, prefix string) (name string, err error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	for i := 0; i < 10000; i++ {
+		try := filepath.Join(dir, prefix+fmt.Sprintf("%d", nextTempSuffix()))
+		err = fs.Mkdir(try, 0700)
+		if os.IsExist(err) {
+			continue
+		}
+		if err == nil {
+			name = try
+		}
+		break
+	}
+	return
+}
+
+func lastIndexByte(s string, b byte) int {
+	for i := len(s) - 1; i >= 0; i-- {
+		if s[i] == b {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,12 @@
+package afero
+
+import "os"
+
+// Lstater is an optional interface in Afero. OsFs implements it with
+// os.Lstat and MemMapFs natively; the wrapping filesystems implement it by
+// delegating to their source (for a union, to the layer or base holding
+// the file). The bool result reports whether Lstat semantics were applied:
+// it is false when a wrapper's source lacks Lstater and Stat was used.
+type Lstater interface {
+	LstatIfPossible(name string) (os.FileInfo, bool, error)
+}
@@ -0,0 +1,232 @@
+package mem
+
+import (
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+// File is an open handle onto a FileData node. Several Files may be open
+// on the same node at once; each keeps its own read/write offset while
+// sharing the node's underlying data and lock.
+type File struct {
+	at           int64
+	readDirCount int64
+	closed       bool
+	readOnly     bool
+	fileData     *FileData
+}
+
+func NewFileHandle(data *FileData) *File {
+	return &File{fileData: data}
+}
+
+func NewReadOnlyFileHandle(data *FileData) *File {
+	return &File{fileData: data, readOnly: true}
+}
+
+func (f *File) Data() *FileData {
+	return f.fileData
+}
+
+var (
+	ErrFileClosed        = errors.New("File is closed")
+	ErrOutOfRange        = errors.New("out of range")
+	ErrTooLarge          = errors.New("too large")
+	ErrFileNotFound      = os.ErrNotExist
+	ErrFileExists        = os.ErrExist
+	ErrDestinationExists = os.ErrExist
+)
+
+func (f *File) Open() error {
+	f.at = 0
+	f.fileData.Lock()
+	f.closed = false
+	f.fileData.Unlock()
+	return nil
+}
+
+func (f *File) Close() error {
+	f.fileData.Lock()
+	defer f.fileData.Unlock()
+	f.closed = true
+	if !f.readOnly {
+		f.fileData.modtime = time.Now()
+	}
+	return nil
+}
+
+func (f *File) Name() string {
+	return f.fileData.name
+}
+
+func (f *File) Stat() (os.FileInfo, error) {
+	return &FileInfo{f.fileData}, nil
+}
+
+func (f *File) Sync() error {
+	return nil
+}
+
+func (f *File) Readdir(count int) (res []os.FileInfo, err error) {
+	if !f.fileData.dir {
+		return nil, &os.PathError{Op: "readdir", Path: f.fileData.name, Err: errors.New("not a dir")}
+	}
+	f.fileData.Lock()
+	files := f.fileData.memDir.Files()[f.readDirCount:]
+	f.fileData.Unlock()
+	if count > 0 {
+		if len(files) == 0 {
+			return nil, io.EOF
+		}
+		if count < len(files) {
+			files = files[:count]
+		}
+	}
+	for _, x := range files {
+		res = append(res, &FileInfo{x})
+	}
+	f.readDirCount += int64(len(files))
+	return res, nil
+}
+
+func (f *File) Readdirnames(n int) (names []string, err error) {
+	fi, err := f.Readdir(n)
+	if err != nil {
+		return nil, err
+	}
+	for _, x := range fi {
+		names = append(names, x.Name())
+	}
+	return names, nil
+}
+
+func (f *File) Read(b []byte) (n int, err error) {
+	f.fileData.Lock()
+	defer f.fileData.Unlock()
+	if f.closed {
+		return 0, ErrFileClosed
+	}
+	if len(b) > 0 && f.at == f.fileData.size {
+		return 0, io.EOF
+	}
+	if f.at > f.fileData.size {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n = readExtents(f.fileData.extents, f.fileData.size, f.at, b)
+	f.at += int64(n)
+	return
+}
+
+func (f *File) ReadAt(b []byte, off int64) (n int, err error) {
+	prev := f.at
+	f.at = off
+	n, err = f.Read(b)
+	f.at = prev
+	return
+}
+
+func (f *File) Truncate(size int64) error {
+	if f.closed {
+		return ErrFileClosed
+	}
+	if f.readOnly {
+		return &os.PathError{Op: "truncate", Path: f.fileData.name, Err: errors.New("file handle is read only")}
+	}
+	if size < 0 {
+		return ErrOutOfRange
+	}
+	f.fileData.Lock()
+	defer f.fileData.Unlock()
+	f.fileData.extents = truncateExtents(f.fileData.extents, size)
+	f.fileData.size = size
+	f.fileData.modtime = time.Now()
+	return nil
+}
+
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, ErrFileClosed
+	}
+	switch whence {
+	case io.SeekStart:
+		f.at = offset
+	case io.SeekCurrent:
+		f.at += offset
+	case io.SeekEnd:
+		f.fileData.Lock()
+		f.at = f.fileData.size + offset
+		f.fileData.Unlock()
+	}
+	return f.at, nil
+}
+
+func (f *File) Write(b []byte) (n int, err error) {
+	if f.readOnly {
+		return 0, &os.PathError{Op: "write", Path: f.fileData.name, Err: errors.New("file handle is read only")}
+	}
+	f.fileData.Lock()
+	defer f.fileData.Unlock()
+	f.fileData.extents, f.fileData.size = writeExtents(f.fileData.extents, f.fileData.size, f.at, b)
+	f.fileData.modtime = time.Now()
+	n = len(b)
+	f.at += int64(n)
+	return
+}
+
+func (f *File) WriteAt(b []byte, off int64) (n int, err error) {
+	prev := f.at
+	f.at = off
+	n, err = f.Write(b)
+	f.at = prev
+	return
+}
+
+func (f *File) WriteString(s string) (ret int, err error) {
+	return f.Write([]byte(s))
+}
+
+// Fallocator flag values, matching the Linux fallocate(2) constants.
+const (
+	FALLOC_FL_KEEP_SIZE  = 0x01
+	FALLOC_FL_PUNCH_HOLE = 0x02
+)
+
+// Fallocate implements afero.Fallocator. With FALLOC_FL_PUNCH_HOLE it
+// frees the backing storage for [offset, offset+length) without
+// changing the file's logical size; reads of that range return zeros.
+// Otherwise it guarantees the file is at least offset+length bytes long,
+// unless FALLOC_FL_KEEP_SIZE is also set.
+func (f *File) Fallocate(offset, length int64, mode uint32) error {
+	if f.readOnly {
+		return &os.PathError{Op: "fallocate", Path: f.fileData.name, Err: errors.New("file handle is read only")}
+	}
+	if offset < 0 || length < 0 {
+		return ErrOutOfRange
+	}
+	f.fileData.Lock()
+	defer f.fileData.Unlock()
+
+	if mode&FALLOC_FL_PUNCH_HOLE != 0 {
+		f.fileData.extents = punchHole(f.fileData.extents, offset, length)
+		f.fileData.modtime = time.Now()
+		return nil
+	}
+
+	needed := offset + length
+	if mode&FALLOC_FL_KEEP_SIZE == 0 && needed > f.fileData.size {
+		f.fileData.size = needed
+		f.fileData.modtime = time.Now()
+	}
+	return nil
+}
+
+// AllocatedBytes reports the number of bytes actually held in memory for
+// the open file's extents, which stays proportional to what has been
+// written rather than to the logical size.
+func (f *File) AllocatedBytes() int64 {
+	f.fileData.Lock()
+	defer f.fileData.Unlock()
+	return extentBytesAllocated(f.fileData.extents)
+}
@@ -0,0 +1,300 @@
+// Package mem provides the in-memory storage used by afero.MemMapFs: the
+// file and directory nodes, and the data each file node carries.
+package mem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const FilePathSeparator = string(os.PathSeparator)
+
+// FileData is the backing data for a single in-memory file or directory
+// node. A node may be referenced by several open *File handles at once;
+// all mutable state lives here and is guarded by the embedded mutex.
+type FileData struct {
+	sync.Mutex
+	name string
+	// extents holds the file's written content as a sorted, non-
+	// overlapping list of byte runs; any logical byte not covered by an
+	// extent is a hole and reads as zero without being materialized.
+	// size is the file's logical length, which can exceed the extents'
+	// combined length when the file has trailing holes.
+	extents   []extent
+	size      int64
+	memDir    Dir
+	dir       bool
+	err       error
+	closers   int
+	mode      os.FileMode
+	modtime   time.Time
+	uid       int
+	gid       int
+	// symlinkTarget is the destination recorded by Symlink. It is empty
+	// for regular files and directories.
+	symlinkTarget string
+	// xattrs holds the node's extended attributes, keyed by attribute
+	// name. It is allocated lazily by SetXattr.
+	xattrs map[string][]byte
+}
+
+// Dir is the interface implemented by the children container of a
+// directory FileData.
+type Dir interface {
+	Len() int
+	Names() []string
+	Files() []*FileData
+	Add(*FileData)
+	Remove(*FileData)
+}
+
+func CreateFile(name string) *FileData {
+	return &FileData{name: name, mode: os.FileMode(0644), modtime: time.Now()}
+}
+
+func CreateDir(name string) *FileData {
+	return &FileData{name: name, memDir: &DirMap{}, dir: true, mode: os.FileMode(0755) | os.ModeDir, modtime: time.Now()}
+}
+
+// CreateSymlink creates a FileData node representing a symbolic link
+// pointing at target. The link's own mode carries os.ModeSymlink; Stat
+// on the owning filesystem follows the link while Lstat reports this
+// node directly.
+func CreateSymlink(name, target string) *FileData {
+	return &FileData{name: name, symlinkTarget: target, mode: os.FileMode(0777) | os.ModeSymlink, modtime: time.Now()}
+}
+
+// Name returns the node's full, slash-cleaned path as last set by
+// CreateFile/CreateDir/CreateSymlink or ChangeFileName.
+func (f *FileData) Name() string {
+	f.Lock()
+	defer f.Unlock()
+	return f.name
+}
+
+func ChangeFileName(f *FileData, newname string) {
+	f.Lock()
+	f.name = newname
+	f.Unlock()
+}
+
+func SetMode(f *FileData, mode os.FileMode) {
+	f.Lock()
+	// preserve the type bits (dir/symlink) while applying the new
+	// permission bits, matching os.Chmod semantics.
+	f.mode = (f.mode & os.ModeType) | (mode &^ os.ModeType)
+	f.Unlock()
+}
+
+func SetModTime(f *FileData, mtime time.Time) {
+	f.Lock()
+	f.modtime = mtime
+	f.Unlock()
+}
+
+func SetUID(f *FileData, uid int) {
+	f.Lock()
+	f.uid = uid
+	f.Unlock()
+}
+
+func SetGID(f *FileData, gid int) {
+	f.Lock()
+	f.gid = gid
+	f.Unlock()
+}
+
+func GetFileInfo(f *FileData) os.FileInfo {
+	return &FileInfo{f}
+}
+
+// IsSymlink reports whether f represents a symbolic link.
+func IsSymlink(f *FileData) bool {
+	return f.mode&os.ModeSymlink != 0
+}
+
+// SymlinkTarget returns the destination recorded for f by CreateSymlink.
+func SymlinkTarget(f *FileData) string {
+	f.Lock()
+	defer f.Unlock()
+	return f.symlinkTarget
+}
+
+// AddToDir registers child as an entry of the directory node parent.
+func AddToDir(parent, child *FileData) {
+	parent.Lock()
+	defer parent.Unlock()
+	parent.memDir.Add(child)
+}
+
+// RemoveFromDir removes child from the directory node parent's entries.
+func RemoveFromDir(parent, child *FileData) {
+	parent.Lock()
+	defer parent.Unlock()
+	parent.memDir.Remove(child)
+}
+
+// DirLen reports how many entries the directory node f currently has.
+func DirLen(f *FileData) int {
+	f.Lock()
+	defer f.Unlock()
+	return f.memDir.Len()
+}
+
+// ClearData truncates f's contents to empty, as by O_TRUNC.
+func ClearData(f *FileData) {
+	f.Lock()
+	defer f.Unlock()
+	f.extents = nil
+	f.size = 0
+	f.modtime = time.Now()
+}
+
+// ErrXattrNotFound is returned by GetXattr/RemoveXattr when attr is not
+// set on f.
+var ErrXattrNotFound = errors.New("attribute not found")
+
+// ErrXattrExists is returned by SetXattr when flags requires the
+// attribute to not already exist, or to already exist, and the actual
+// state disagrees.
+var ErrXattrExists = errors.New("attribute already exists or does not exist, per the requested flags")
+
+// GetXattr returns a copy of the named extended attribute's value.
+func GetXattr(f *FileData, attr string) ([]byte, error) {
+	f.Lock()
+	defer f.Unlock()
+	v, ok := f.xattrs[attr]
+	if !ok {
+		return nil, ErrXattrNotFound
+	}
+	out := make([]byte, len(v))
+	copy(out, v)
+	return out, nil
+}
+
+// SetXattr sets the named extended attribute to a copy of data, honoring
+// the XATTR_CREATE/XATTR_REPLACE flag semantics documented on
+// afero.XattrFs.Setxattr.
+func SetXattr(f *FileData, attr string, data []byte, flags int) error {
+	const (
+		xattrCreate  = 0x1
+		xattrReplace = 0x2
+	)
+	f.Lock()
+	defer f.Unlock()
+	_, exists := f.xattrs[attr]
+	if flags&xattrCreate != 0 && exists {
+		return ErrXattrExists
+	}
+	if flags&xattrReplace != 0 && !exists {
+		return ErrXattrExists
+	}
+	if f.xattrs == nil {
+		f.xattrs = make(map[string][]byte)
+	}
+	v := make([]byte, len(data))
+	copy(v, data)
+	f.xattrs[attr] = v
+	return nil
+}
+
+// ListXattr returns the names of all extended attributes set on f.
+func ListXattr(f *FileData) []string {
+	f.Lock()
+	defer f.Unlock()
+	names := make([]string, 0, len(f.xattrs))
+	for k := range f.xattrs {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RemoveXattr removes the named extended attribute from f.
+func RemoveXattr(f *FileData, attr string) error {
+	f.Lock()
+	defer f.Unlock()
+	if _, ok := f.xattrs[attr]; !ok {
+		return ErrXattrNotFound
+	}
+	delete(f.xattrs, attr)
+	return nil
+}
+
+type DirMap map[string]*FileData
+
+func (m DirMap) Len() int      { return len(m) }
+func (m DirMap) Add(f *FileData) { m[f.name] = f }
+func (m DirMap) Remove(f *FileData) { delete(m, f.name) }
+
+func (m DirMap) Files() (files []*FileData) {
+	for _, f := range m {
+		files = append(files, f)
+	}
+	sort.Sort(filesSorter(files))
+	return files
+}
+
+func (m DirMap) Names() (names []string) {
+	for x := range m {
+		names = append(names, x)
+	}
+	return names
+}
+
+type filesSorter []*FileData
+
+func (s filesSorter) Len() int           { return len(s) }
+func (s filesSorter) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s filesSorter) Less(i, j int) bool { return s[i].name < s[j].name }
+
+// FileInfo wraps a FileData to satisfy os.FileInfo. When the underlying
+// node is a symlink, Mode/IsDir report the link itself (Lstat semantics);
+// MemMapFs.Stat resolves the link before ever constructing a FileInfo, so
+// callers going through Stat never observe ModeSymlink here.
+type FileInfo struct {
+	*FileData
+}
+
+func (s *FileInfo) Name() string {
+	s.Lock()
+	defer s.Unlock()
+	_, name := filepath.Split(s.name)
+	return name
+}
+
+func (s *FileInfo) Mode() os.FileMode {
+	s.Lock()
+	defer s.Unlock()
+	return s.mode
+}
+
+func (s *FileInfo) ModTime() time.Time {
+	s.Lock()
+	defer s.Unlock()
+	return s.modtime
+}
+
+func (s *FileInfo) IsDir() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.dir
+}
+
+func (s *FileInfo) Sys() interface{} { return nil }
+
+func (s *FileInfo) Size() int64 {
+	if s.IsDir() {
+		return int64(42)
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.symlinkTarget != "" {
+		return int64(len(s.symlinkTarget))
+	}
+	return s.size
+}
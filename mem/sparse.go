@@ -0,0 +1,146 @@
+package mem
+
+import "sort"
+
+// extent is a contiguous run of bytes starting at a logical offset. A
+// FileData's content is the sorted, non-overlapping list of its
+// extents; any logical byte not covered by an extent is a hole and
+// reads as zero without ever being materialized in memory.
+type extent struct {
+	offset int64
+	data   []byte
+}
+
+func (e extent) end() int64 { return e.offset + int64(len(e.data)) }
+
+// readExtents fills buf (interpreted as the logical range [off, off+len(buf)))
+// with the file's content, returning holes as zero bytes, and reports how
+// many bytes of buf fall within the file's logical size.
+func readExtents(extents []extent, size int64, off int64, buf []byte) int {
+	if off >= size {
+		return 0
+	}
+	n := int64(len(buf))
+	if off+n > size {
+		n = size - off
+	}
+	for i := range buf[:n] {
+		buf[i] = 0
+	}
+	for _, e := range extents {
+		lo := e.offset
+		hi := e.end()
+		if hi <= off || lo >= off+n {
+			continue
+		}
+		if lo < off {
+			lo = off
+		}
+		if hi > off+n {
+			hi = off + n
+		}
+		copy(buf[lo-off:hi-off], e.data[lo-e.offset:hi-e.offset])
+	}
+	return int(n)
+}
+
+// writeExtents returns the extent list and logical size that result from
+// writing data at offset, replacing or extending any overlapping extents.
+func writeExtents(extents []extent, size int64, offset int64, data []byte) ([]extent, int64) {
+	if len(data) == 0 {
+		if offset > size {
+			size = offset
+		}
+		return extents, size
+	}
+
+	newExtent := extent{offset: offset, data: append([]byte(nil), data...)}
+	end := newExtent.end()
+
+	var kept []extent
+	for _, e := range extents {
+		pieces := subtract(e, offset, end)
+		kept = append(kept, pieces...)
+	}
+	kept = append(kept, newExtent)
+	sort.Slice(kept, func(i, j int) bool { return kept[i].offset < kept[j].offset })
+	kept = coalesce(kept)
+
+	if end > size {
+		size = end
+	}
+	return kept, size
+}
+
+// punchHole zeroes (frees) the logical range [offset, offset+length)
+// without changing the file's logical size.
+func punchHole(extents []extent, offset, length int64) []extent {
+	if length <= 0 {
+		return extents
+	}
+	end := offset + length
+	var kept []extent
+	for _, e := range extents {
+		kept = append(kept, subtract(e, offset, end)...)
+	}
+	return kept
+}
+
+// truncateExtents drops (or trims) any extent data beyond newSize.
+func truncateExtents(extents []extent, newSize int64) []extent {
+	var kept []extent
+	for _, e := range extents {
+		if e.offset >= newSize {
+			continue
+		}
+		if e.end() > newSize {
+			e.data = e.data[:newSize-e.offset]
+		}
+		kept = append(kept, e)
+	}
+	return kept
+}
+
+// subtract removes the logical range [start, end) from e, returning the
+// zero, one, or two remaining pieces.
+func subtract(e extent, start, end int64) []extent {
+	if end <= e.offset || start >= e.end() {
+		return []extent{e}
+	}
+	var out []extent
+	if start > e.offset {
+		out = append(out, extent{offset: e.offset, data: e.data[:start-e.offset]})
+	}
+	if end < e.end() {
+		out = append(out, extent{offset: end, data: e.data[end-e.offset:]})
+	}
+	return out
+}
+
+// coalesce merges adjacent extents in a sorted, non-overlapping slice.
+func coalesce(extents []extent) []extent {
+	if len(extents) == 0 {
+		return extents
+	}
+	out := extents[:1]
+	for _, e := range extents[1:] {
+		last := &out[len(out)-1]
+		if last.end() == e.offset {
+			last.data = append(last.data, e.data...)
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// extentBytesAllocated sums the in-memory footprint of a file's
+// extents, for diagnostics/tests: proportional to written data, not
+// logical size.
+func extentBytesAllocated(extents []extent) int64 {
+	var n int64
+	for _, e := range extents {
+		n += int64(len(e.data))
+	}
+	return n
+}
@@ -0,0 +1,549 @@
+package afero
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getporter/afero/mem"
+)
+
+// chmodBits holds the permission bits that Chmod is allowed to alter; the
+// file-type bits (directory, symlink, ...) are preserved across a Chmod.
+const chmodBits = os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky
+
+// MemMapFs is an in-memory, thread-safe implementation of Fs. All paths
+// are normalized and stored in a flat map keyed by their cleaned form
+// (filepath.Clean, OS separator); directories carry the set of their
+// children so that Readdir works without walking the map. Rename and
+// RemoveAll of a subtree scan the whole map for matching paths.
+type MemMapFs struct {
+	mu   sync.RWMutex
+	data map[string]*mem.FileData
+	init sync.Once
+
+	watchMu  sync.Mutex
+	watchers []*Watcher
+}
+
+// Watch returns a Watcher that reports Create/Write/Remove/Rename/Chmod
+// events for path and everything below it, until the returned Watcher is
+// closed. Writes are coalesced into a single OpWrite event delivered
+// when the file handle that wrote them is closed.
+func (m *MemMapFs) Watch(path string) (*Watcher, error) {
+	path = normalizePath(path)
+	w := newWatcher(path)
+	m.watchMu.Lock()
+	m.watchers = append(m.watchers, w)
+	m.watchMu.Unlock()
+	return w, nil
+}
+
+func (m *MemMapFs) hasWatchers() bool {
+	m.watchMu.Lock()
+	defer m.watchMu.Unlock()
+	return len(m.watchers) > 0
+}
+
+// notify fans ev out to every live watcher whose path matches name,
+// pruning closed watchers as it goes. It must be called without m.mu
+// held, so that a slow or misbehaving reader on a Watcher's channel
+// never stalls other filesystem operations; only the operation that
+// produced the event waits, for at most watcherSendTimeout per watcher.
+func (m *MemMapFs) notify(name string, op Op) {
+	m.watchMu.Lock()
+	live := m.watchers[:0]
+	for _, w := range m.watchers {
+		if !w.isClosed() {
+			live = append(live, w)
+		}
+	}
+	m.watchers = live
+	watchers := append([]*Watcher(nil), live...)
+	m.watchMu.Unlock()
+
+	for _, w := range watchers {
+		if w.matches(name) {
+			w.send(Event{Name: name, Op: op})
+		}
+	}
+}
+
+// wrapForWatch wraps f so that writes are coalesced into a single
+// OpWrite event fired on Close, but only when there is at least one
+// watcher to notify -- an unwatched Fs pays nothing extra.
+func (m *MemMapFs) wrapForWatch(name string, f File) File {
+	if !m.hasWatchers() {
+		return f
+	}
+	return &watchFile{File: f, fs: m, name: name}
+}
+
+// watchFile wraps a File to fire a coalesced OpWrite notification on
+// Close if any write actually occurred.
+type watchFile struct {
+	File
+	fs    *MemMapFs
+	name  string
+	dirty bool
+}
+
+func (f *watchFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	if n > 0 {
+		f.dirty = true
+	}
+	return n, err
+}
+
+func (f *watchFile) WriteAt(p []byte, off int64) (int, error) {
+	n, err := f.File.WriteAt(p, off)
+	if n > 0 {
+		f.dirty = true
+	}
+	return n, err
+}
+
+func (f *watchFile) WriteString(s string) (int, error) {
+	n, err := f.File.WriteString(s)
+	if n > 0 {
+		f.dirty = true
+	}
+	return n, err
+}
+
+func (f *watchFile) Truncate(size int64) error {
+	err := f.File.Truncate(size)
+	if err == nil {
+		f.dirty = true
+	}
+	return err
+}
+
+func (f *watchFile) Close() error {
+	err := f.File.Close()
+	if f.dirty {
+		f.fs.notify(f.name, OpWrite)
+	}
+	return err
+}
+
+func NewMemMapFs() Fs {
+	return &MemMapFs{}
+}
+
+func (m *MemMapFs) getData() map[string]*mem.FileData {
+	m.init.Do(func() {
+		m.data = make(map[string]*mem.FileData)
+		root := mem.CreateDir(FilePathSeparator)
+		mem.SetMode(root, os.ModeDir|0755)
+		m.data[FilePathSeparator] = root
+	})
+	return m.data
+}
+
+func (m *MemMapFs) Name() string { return "MemMapFS" }
+
+// lockedFind looks up name in the data map; the caller must already hold
+// m.mu for reading (or writing).
+func (m *MemMapFs) lockedFind(name string) (*mem.FileData, bool) {
+	f, ok := m.getData()[name]
+	return f, ok
+}
+
+func (m *MemMapFs) findParent(f *mem.FileData) *mem.FileData {
+	pdir, _ := filepath.Split(f.Name())
+	pdir = normalizePath(pdir)
+	parent, ok := m.lockedFind(pdir)
+	if !ok {
+		return nil
+	}
+	return parent
+}
+
+// registerWithParent must be called with m.mu held for writing.
+func (m *MemMapFs) registerWithParent(f *mem.FileData, perm os.FileMode) {
+	parent := m.findParent(f)
+	if parent == nil {
+		pdir := filepath.Dir(filepath.Clean(f.Name()))
+		m.lockedMkdir(pdir, perm)
+		parent, _ = m.lockedFind(pdir)
+		if parent == nil {
+			return
+		}
+	}
+	mem.AddToDir(parent, f)
+}
+
+func (m *MemMapFs) unRegisterWithParent(fileName string) error {
+	f, ok := m.lockedFind(fileName)
+	if !ok {
+		return &os.PathError{Op: "remove", Path: fileName, Err: os.ErrNotExist}
+	}
+	parent := m.findParent(f)
+	if parent == nil {
+		// Already detached, e.g. a RemoveAll that deleted this file's
+		// parent directory entry earlier in the same sweep.
+		return nil
+	}
+	mem.RemoveFromDir(parent, f)
+	return nil
+}
+
+// lockedMkdir must be called with m.mu held for writing.
+func (m *MemMapFs) lockedMkdir(name string, perm os.FileMode) error {
+	name = normalizePath(name)
+	if x, ok := m.lockedFind(name); ok {
+		if !mem.GetFileInfo(x).IsDir() {
+			return ErrFileExists
+		}
+		return nil
+	}
+	item := mem.CreateDir(name)
+	mem.SetMode(item, os.ModeDir|perm)
+	m.getData()[name] = item
+	m.registerWithParent(item, perm)
+	return nil
+}
+
+func (m *MemMapFs) Mkdir(name string, perm os.FileMode) error {
+	perm &= chmodBits
+	name = normalizePath(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.lockedFind(name); ok {
+		return &os.PathError{Op: "mkdir", Path: name, Err: ErrFileExists}
+	}
+	item := mem.CreateDir(name)
+	mem.SetMode(item, os.ModeDir|perm)
+	m.getData()[name] = item
+	m.registerWithParent(item, perm)
+	return nil
+}
+
+func (m *MemMapFs) MkdirAll(path string, perm os.FileMode) error {
+	path = normalizePath(path)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var parts []string
+	p := path
+	for {
+		parts = append([]string{p}, parts...)
+		parent := filepath.Dir(p)
+		if parent == p {
+			break
+		}
+		p = parent
+	}
+	for _, part := range parts {
+		if err := m.lockedMkdir(part, perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create creates a file, truncating it if it already exists.
+func (m *MemMapFs) Create(name string) (File, error) {
+	name = normalizePath(name)
+
+	m.mu.Lock()
+	file := mem.CreateFile(name)
+	m.getData()[name] = file
+	m.registerWithParent(file, 0)
+	m.mu.Unlock()
+
+	m.notify(name, OpCreate)
+	return m.wrapForWatch(name, mem.NewFileHandle(file)), nil
+}
+
+func (m *MemMapFs) resolve(name string) (*mem.FileData, error) {
+	name = normalizePath(name)
+	f, ok := m.lockedFind(name)
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	seen := map[string]bool{}
+	for mem.IsSymlink(f) {
+		if seen[f.Name()] {
+			return nil, &os.PathError{Op: "open", Path: name, Err: fmt.Errorf("too many levels of symbolic links")}
+		}
+		seen[f.Name()] = true
+		target := mem.SymlinkTarget(f)
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(f.Name()), target)
+		}
+		target = normalizePath(target)
+		next, ok := m.lockedFind(target)
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		f = next
+	}
+	return f, nil
+}
+
+func (m *MemMapFs) Open(name string) (File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+func (m *MemMapFs) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	name = normalizePath(name)
+
+	m.mu.Lock()
+
+	f, err := m.resolve(name)
+	if err != nil {
+		if flag&os.O_CREATE == 0 {
+			m.mu.Unlock()
+			return nil, err
+		}
+		file := mem.CreateFile(name)
+		mem.SetMode(file, perm)
+		m.getData()[name] = file
+		m.registerWithParent(file, perm)
+		m.mu.Unlock()
+
+		m.notify(name, OpCreate)
+		return m.wrapForWatch(name, mem.NewFileHandle(file)), nil
+	}
+
+	if flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL {
+		m.mu.Unlock()
+		return nil, &os.PathError{Op: "open", Path: name, Err: ErrFileExists}
+	}
+
+	truncated := false
+	if flag&os.O_TRUNC != 0 {
+		mem.ClearData(f)
+		truncated = true
+	}
+
+	var handle File
+	if flag&(os.O_RDWR|os.O_WRONLY) == 0 {
+		handle = mem.NewReadOnlyFileHandle(f)
+	} else {
+		handle = mem.NewFileHandle(f)
+	}
+	if flag&os.O_APPEND != 0 {
+		handle.Seek(0, os.SEEK_END)
+	}
+	m.mu.Unlock()
+
+	if truncated {
+		m.notify(name, OpWrite)
+	}
+	return m.wrapForWatch(name, handle), nil
+}
+
+func (m *MemMapFs) Remove(name string) error {
+	name = normalizePath(name)
+
+	m.mu.Lock()
+
+	if f, ok := m.lockedFind(name); ok {
+		if mem.GetFileInfo(f).IsDir() && mem.DirLen(f) > 0 {
+			m.mu.Unlock()
+			return &os.PathError{Op: "remove", Path: name, Err: fmt.Errorf("directory not empty")}
+		}
+		m.unRegisterWithParent(name)
+		delete(m.getData(), name)
+		m.mu.Unlock()
+
+		m.notify(name, OpRemove)
+		return nil
+	}
+	m.mu.Unlock()
+	return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+}
+
+func (m *MemMapFs) RemoveAll(path string) error {
+	path = normalizePath(path)
+
+	m.mu.Lock()
+	var removed []string
+	for p := range m.getData() {
+		if p == path || strings.HasPrefix(p, path+FilePathSeparator) {
+			m.unRegisterWithParent(p)
+			delete(m.getData(), p)
+			removed = append(removed, p)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, p := range removed {
+		m.notify(p, OpRemove)
+	}
+	return nil
+}
+
+func (m *MemMapFs) Rename(oldname, newname string) error {
+	oldname = normalizePath(oldname)
+	newname = normalizePath(newname)
+
+	m.mu.Lock()
+
+	if oldname == newname {
+		m.mu.Unlock()
+		return nil
+	}
+
+	var matched []string
+	for p := range m.getData() {
+		if p == oldname || strings.HasPrefix(p, oldname+FilePathSeparator) {
+			matched = append(matched, p)
+		}
+	}
+	if len(matched) == 0 {
+		m.mu.Unlock()
+		return &os.PathError{Op: "rename", Path: oldname, Err: os.ErrNotExist}
+	}
+	// Process shallowest paths first so that a child's new parent
+	// directory already exists under its new name by the time the child
+	// is re-registered.
+	sort.Strings(matched)
+
+	for _, p := range matched {
+		f := m.getData()[p]
+		newPath := newname + p[len(oldname):]
+		m.unRegisterWithParent(p)
+		delete(m.getData(), p)
+		mem.ChangeFileName(f, newPath)
+		m.getData()[newPath] = f
+		m.registerWithParent(f, 0)
+	}
+	m.mu.Unlock()
+
+	m.notify(oldname, OpRename)
+	return nil
+}
+
+// Stat resolves symlinks before returning file information, matching
+// os.Stat. Use Lstat to inspect a symlink itself.
+func (m *MemMapFs) Stat(name string) (os.FileInfo, error) {
+	name = normalizePath(name)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, err := m.resolve(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	return mem.GetFileInfo(f), nil
+}
+
+// LstatIfPossible implements Lstater: it always reports the file itself,
+// without following a trailing symlink, and reports true to indicate
+// Lstat semantics were actually applied.
+func (m *MemMapFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	name = normalizePath(name)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, ok := m.lockedFind(name)
+	if !ok {
+		return nil, true, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+	}
+	return mem.GetFileInfo(f), true, nil
+}
+
+// Symlink creates newname as a symbolic link to oldname. oldname is
+// stored verbatim (relative targets are resolved relative to newname's
+// directory when the link is followed) so that Readlink round-trips it.
+func (m *MemMapFs) Symlink(oldname, newname string) error {
+	newname = normalizePath(newname)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.lockedFind(newname); ok {
+		return &os.PathError{Op: "symlink", Path: newname, Err: ErrFileExists}
+	}
+	link := mem.CreateSymlink(newname, oldname)
+	m.getData()[newname] = link
+	m.registerWithParent(link, 0)
+	return nil
+}
+
+// Readlink returns the destination recorded by Symlink for name.
+func (m *MemMapFs) Readlink(name string) (string, error) {
+	name = normalizePath(name)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, ok := m.lockedFind(name)
+	if !ok {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: os.ErrNotExist}
+	}
+	if !mem.IsSymlink(f) {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: fmt.Errorf("invalid argument")}
+	}
+	return mem.SymlinkTarget(f), nil
+}
+
+func (m *MemMapFs) Chmod(name string, mode os.FileMode) error {
+	name = normalizePath(name)
+
+	m.mu.Lock()
+	f, ok := m.lockedFind(name)
+	if !ok {
+		m.mu.Unlock()
+		return &os.PathError{Op: "chmod", Path: name, Err: os.ErrNotExist}
+	}
+	mem.SetMode(f, mode&chmodBits)
+	m.mu.Unlock()
+
+	m.notify(name, OpChmod)
+	return nil
+}
+
+func (m *MemMapFs) Chown(name string, uid, gid int) error {
+	name = normalizePath(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, ok := m.lockedFind(name)
+	if !ok {
+		return &os.PathError{Op: "chown", Path: name, Err: os.ErrNotExist}
+	}
+	mem.SetUID(f, uid)
+	mem.SetGID(f, gid)
+	return nil
+}
+
+func (m *MemMapFs) Chtimes(name string, atime time.Time, mtime time.Time) error {
+	name = normalizePath(name)
+
+	m.mu.Lock()
+	f, ok := m.lockedFind(name)
+	if !ok {
+		m.mu.Unlock()
+		return &os.PathError{Op: "chtimes", Path: name, Err: os.ErrNotExist}
+	}
+	mem.SetModTime(f, mtime)
+	m.mu.Unlock()
+
+	m.notify(name, OpChmod)
+	return nil
+}
+
+var ErrFileExists = fmt.Errorf("file already exists")
+
+func normalizePath(path string) string {
+	path = filepath.Clean(path)
+
+	switch path {
+	case ".":
+		return FilePathSeparator
+	case "..":
+		return FilePathSeparator
+	default:
+		return path
+	}
+}
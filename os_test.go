@@ -0,0 +1,29 @@
+package afero
+
+import "testing"
+
+// Fss lists the Fs implementations exercised by the cross-implementation
+// tests in this package (e.g. TestMultipleOpenFiles, TestReadOnly).
+var Fss = []Fs{&MemMapFs{}, &OsFs{}}
+
+var testDirs = map[Fs][]string{}
+
+func testDir(fs Fs) string {
+	name, err := TempDir(fs, "", "afero")
+	if err != nil {
+		panic(err)
+	}
+	testDirs[fs] = append(testDirs[fs], name)
+	return name
+}
+
+func removeAllTestFiles(t *testing.T) {
+	for fs, dirs := range testDirs {
+		for _, dir := range dirs {
+			if err := fs.RemoveAll(dir); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	testDirs = map[Fs][]string{}
+}
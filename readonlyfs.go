@@ -0,0 +1,92 @@
+package afero
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// ReadOnlyFs wraps another Fs and rejects every mutating operation with
+// syscall.EROFS, passing reads straight through.
+type ReadOnlyFs struct {
+	source Fs
+}
+
+func NewReadOnlyFs(source Fs) Fs {
+	return &ReadOnlyFs{source: source}
+}
+
+func (r *ReadOnlyFs) Name() string {
+	return "ReadOnlyFilter"
+}
+
+func (r *ReadOnlyFs) Chtimes(n string, a, m time.Time) error {
+	return syscall.EROFS
+}
+
+func (r *ReadOnlyFs) Chmod(n string, mode os.FileMode) error {
+	return syscall.EROFS
+}
+
+func (r *ReadOnlyFs) Chown(n string, uid, gid int) error {
+	return syscall.EROFS
+}
+
+func (r *ReadOnlyFs) Stat(name string) (os.FileInfo, error) {
+	return r.source.Stat(name)
+}
+
+// LstatIfPossible delegates to the source's Lstat when available; since
+// Lstat never mutates the filesystem there is nothing to reject here.
+func (r *ReadOnlyFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	return lstatIfPossible(r.source, name)
+}
+
+// Readlink passes through to the source filesystem, if it supports it.
+func (r *ReadOnlyFs) Readlink(name string) (string, error) {
+	linkReader, ok := r.source.(LinkReader)
+	if !ok {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: ErrNoReadlink}
+	}
+	return linkReader.Readlink(name)
+}
+
+// Symlink always fails: creating a link mutates the directory it lives in.
+func (r *ReadOnlyFs) Symlink(oldname, newname string) error {
+	return syscall.EROFS
+}
+
+func (r *ReadOnlyFs) Rename(o, n string) error {
+	return syscall.EROFS
+}
+
+func (r *ReadOnlyFs) RemoveAll(p string) error {
+	return syscall.EROFS
+}
+
+func (r *ReadOnlyFs) Remove(n string) error {
+	return syscall.EROFS
+}
+
+func (r *ReadOnlyFs) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC) != 0 {
+		return nil, syscall.EROFS
+	}
+	return r.source.OpenFile(name, flag, perm)
+}
+
+func (r *ReadOnlyFs) Open(n string) (File, error) {
+	return r.source.Open(n)
+}
+
+func (r *ReadOnlyFs) Mkdir(n string, p os.FileMode) error {
+	return syscall.EROFS
+}
+
+func (r *ReadOnlyFs) MkdirAll(n string, p os.FileMode) error {
+	return syscall.EROFS
+}
+
+func (r *ReadOnlyFs) Create(n string) (File, error) {
+	return nil, syscall.EROFS
+}
@@ -0,0 +1,211 @@
+package afero
+
+import (
+	"os"
+	"regexp"
+	"syscall"
+	"time"
+)
+
+// RegexpFs filters directory listings of its source Fs down to entries
+// matching a regular expression; operations on a name that doesn't match
+// fail with os.ErrNotExist (mutating the backing Fs through a name that
+// does match still works, including for directories, which always pass).
+type RegexpFs struct {
+	source Fs
+	re     *regexp.Regexp
+}
+
+func NewRegexpFs(source Fs, re *regexp.Regexp) Fs {
+	return &RegexpFs{source: source, re: re}
+}
+
+type RegexpFile struct {
+	f  File
+	fs *RegexpFs
+}
+
+func (r *RegexpFs) matchesName(name string) error {
+	if r.re == nil {
+		return nil
+	}
+	if r.re.MatchString(name) {
+		return nil
+	}
+	return syscall.ENOENT
+}
+
+func (r *RegexpFs) dirOrMatches(name string) error {
+	dir, err := IsDir(r.source, name)
+	if err != nil {
+		return err
+	}
+	if dir {
+		return nil
+	}
+	return r.matchesName(name)
+}
+
+func (r *RegexpFs) Chtimes(name string, a, m time.Time) error {
+	if err := r.dirOrMatches(name); err != nil {
+		return err
+	}
+	return r.source.Chtimes(name, a, m)
+}
+
+func (r *RegexpFs) Chmod(name string, mode os.FileMode) error {
+	if err := r.dirOrMatches(name); err != nil {
+		return err
+	}
+	return r.source.Chmod(name, mode)
+}
+
+func (r *RegexpFs) Chown(name string, uid, gid int) error {
+	if err := r.dirOrMatches(name); err != nil {
+		return err
+	}
+	return r.source.Chown(name, uid, gid)
+}
+
+func (r *RegexpFs) Name() string {
+	return "RegexpFs"
+}
+
+func (r *RegexpFs) Stat(name string) (os.FileInfo, error) {
+	if err := r.dirOrMatches(name); err != nil {
+		return nil, err
+	}
+	return r.source.Stat(name)
+}
+
+// LstatIfPossible reports the entry itself, without following a trailing
+// symlink, subject to the same name filtering as Stat.
+func (r *RegexpFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	if err := r.dirOrMatches(name); err != nil {
+		return nil, false, err
+	}
+	return lstatIfPossible(r.source, name)
+}
+
+func (r *RegexpFs) Rename(oldname, newname string) error {
+	if err := r.dirOrMatches(oldname); err != nil {
+		return err
+	}
+	if err := r.matchesName(newname); err != nil {
+		return err
+	}
+	return r.source.Rename(oldname, newname)
+}
+
+func (r *RegexpFs) RemoveAll(p string) error {
+	if err := r.dirOrMatches(p); err != nil {
+		return err
+	}
+	return r.source.RemoveAll(p)
+}
+
+func (r *RegexpFs) Remove(name string) error {
+	if err := r.dirOrMatches(name); err != nil {
+		return err
+	}
+	return r.source.Remove(name)
+}
+
+func (r *RegexpFs) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if err := r.dirOrMatches(name); err != nil {
+		return nil, err
+	}
+	sourcef, err := r.source.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &RegexpFile{f: sourcef, fs: r}, nil
+}
+
+func (r *RegexpFs) Open(name string) (File, error) {
+	if err := r.dirOrMatches(name); err != nil {
+		return nil, err
+	}
+	sourcef, err := r.source.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &RegexpFile{f: sourcef, fs: r}, nil
+}
+
+// Symlink creates newname as a symbolic link to oldname, provided the
+// source Fs supports it and newname matches the filter.
+func (r *RegexpFs) Symlink(oldname, newname string) error {
+	symlinker, ok := r.source.(Symlinker)
+	if !ok {
+		return &os.LinkError{Op: "symlink", Old: oldname, New: newname, Err: ErrNoSymlink}
+	}
+	if err := r.matchesName(newname); err != nil {
+		return err
+	}
+	return symlinker.Symlink(oldname, newname)
+}
+
+// Readlink returns the destination of the named symbolic link, subject
+// to the same name filtering as Stat.
+func (r *RegexpFs) Readlink(name string) (string, error) {
+	linkReader, ok := r.source.(LinkReader)
+	if !ok {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: ErrNoReadlink}
+	}
+	if err := r.dirOrMatches(name); err != nil {
+		return "", err
+	}
+	return linkReader.Readlink(name)
+}
+
+func (r *RegexpFs) Mkdir(name string, perm os.FileMode) error {
+	return r.source.Mkdir(name, perm)
+}
+
+func (r *RegexpFs) MkdirAll(name string, perm os.FileMode) error {
+	return r.source.MkdirAll(name, perm)
+}
+
+func (r *RegexpFs) Create(name string) (File, error) {
+	if err := r.matchesName(name); err != nil {
+		return nil, err
+	}
+	return r.source.Create(name)
+}
+
+func (f *RegexpFile) Close() error                                 { return f.f.Close() }
+func (f *RegexpFile) Read(s []byte) (int, error)                   { return f.f.Read(s) }
+func (f *RegexpFile) ReadAt(s []byte, o int64) (int, error)         { return f.f.ReadAt(s, o) }
+func (f *RegexpFile) Seek(o int64, w int) (int64, error)            { return f.f.Seek(o, w) }
+func (f *RegexpFile) Write(s []byte) (int, error)                   { return f.f.Write(s) }
+func (f *RegexpFile) WriteAt(s []byte, o int64) (int, error)        { return f.f.WriteAt(s, o) }
+func (f *RegexpFile) Name() string                                  { return f.f.Name() }
+func (f *RegexpFile) Stat() (os.FileInfo, error)                    { return f.f.Stat() }
+func (f *RegexpFile) Sync() error                                   { return f.f.Sync() }
+func (f *RegexpFile) Truncate(size int64) error                     { return f.f.Truncate(size) }
+func (f *RegexpFile) WriteString(s string) (int, error)             { return f.f.WriteString(s) }
+
+func (f *RegexpFile) Readdir(c int) (fi []os.FileInfo, err error) {
+	entries, err := f.f.Readdir(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() || f.fs.matchesName(e.Name()) == nil {
+			fi = append(fi, e)
+		}
+	}
+	return fi, nil
+}
+
+func (f *RegexpFile) Readdirnames(c int) (n []string, err error) {
+	fi, err := f.Readdir(c)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range fi {
+		n = append(n, s.Name())
+	}
+	return n, nil
+}
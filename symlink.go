@@ -0,0 +1,27 @@
+package afero
+
+import "errors"
+
+// Symlinker is implemented by filesystems that can create symbolic
+// links. Callers that need Symlink should type-assert an Fs to this
+// interface rather than adding it to Fs itself, since not every Fs
+// implementation has a sensible notion of a symlink.
+type Symlinker interface {
+	// Symlink creates newname as a symbolic link to oldname.
+	Symlink(oldname, newname string) error
+}
+
+// LinkReader is implemented by filesystems that can read back the
+// target of a symbolic link created via Symlinker.
+type LinkReader interface {
+	// Readlink returns the destination of the named symbolic link.
+	Readlink(name string) (string, error)
+}
+
+// ErrNoSymlink is returned by Symlink wrappers (e.g. BasePathFs) whose
+// underlying Fs does not implement Symlinker.
+var ErrNoSymlink = errors.New("symlink not supported")
+
+// ErrNoReadlink is returned by Readlink wrappers whose underlying Fs does
+// not implement LinkReader.
+var ErrNoReadlink = errors.New("readlink not supported")
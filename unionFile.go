@@ -0,0 +1,179 @@
+package afero
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// UnionFile is a structure adapter that holds a base and a layer File.
+// When a layer file is present, reads and writes both go to it (a read
+// also advances the base's offset by the bytes read); without one, reads
+// go to the base and writes fail with EROFS, so nothing writes to the
+// base. Readdir merges entries from both.
+type UnionFile struct {
+	base   File
+	layer  File
+	off    int
+	files  []os.FileInfo
+	merged bool
+
+	// whiteouts holds the names (not full paths, just Base()) of entries
+	// that CopyOnWriteFs has recorded as deleted from the base layer;
+	// Readdir drops them from the merged listing. Nil for a UnionFile
+	// not backed by a whiteout-tracking Fs.
+	whiteouts map[string]bool
+}
+
+func (f *UnionFile) Close() error {
+	// close the layer first since it's the one we hand out for writes
+	if f.layer != nil {
+		f.layer.Close()
+	}
+	if f.base != nil {
+		return f.base.Close()
+	}
+	return nil
+}
+
+func (f *UnionFile) Read(s []byte) (int, error) {
+	if f.layer != nil {
+		n, err := f.layer.Read(s)
+		if (err == nil || err == io.EOF) && f.base != nil {
+			f.base.Seek(int64(n), io.SeekCurrent)
+		}
+		return n, err
+	}
+	return f.base.Read(s)
+}
+
+func (f *UnionFile) ReadAt(s []byte, o int64) (int, error) {
+	if f.layer != nil {
+		return f.layer.ReadAt(s, o)
+	}
+	return f.base.ReadAt(s, o)
+}
+
+func (f *UnionFile) Seek(o int64, w int) (pos int64, err error) {
+	if f.layer != nil {
+		pos, err = f.layer.Seek(o, w)
+		if err == nil && f.base != nil {
+			f.base.Seek(o, w)
+		}
+		return
+	}
+	return f.base.Seek(o, w)
+}
+
+func (f *UnionFile) Write(s []byte) (n int, err error) {
+	if f.layer != nil {
+		return f.layer.Write(s)
+	}
+	return 0, &os.PathError{Op: "write", Path: f.Name(), Err: syscall.EROFS}
+}
+
+func (f *UnionFile) WriteAt(s []byte, o int64) (n int, err error) {
+	if f.layer != nil {
+		return f.layer.WriteAt(s, o)
+	}
+	return 0, &os.PathError{Op: "write", Path: f.Name(), Err: syscall.EROFS}
+}
+
+func (f *UnionFile) Name() string {
+	if f.layer != nil {
+		return f.layer.Name()
+	}
+	return f.base.Name()
+}
+
+func (f *UnionFile) Stat() (os.FileInfo, error) {
+	if f.layer != nil {
+		return f.layer.Stat()
+	}
+	return f.base.Stat()
+}
+
+func (f *UnionFile) Sync() error {
+	if f.layer != nil {
+		return f.layer.Sync()
+	}
+	return f.base.Sync()
+}
+
+func (f *UnionFile) Truncate(size int64) error {
+	if f.layer != nil {
+		return f.layer.Truncate(size)
+	}
+	return &os.PathError{Op: "truncate", Path: f.Name(), Err: syscall.EROFS}
+}
+
+func (f *UnionFile) WriteString(s string) (int, error) {
+	if f.layer != nil {
+		return f.layer.WriteString(s)
+	}
+	return 0, &os.PathError{Op: "write", Path: f.Name(), Err: syscall.EROFS}
+}
+
+// mergeReaddirs merges two, already sorted by name, os.FileInfo slices,
+// with entries from lofi (the writable layer) taking precedence over
+// basefi (the read-only base) on a name collision, and dropping any
+// whiteout entries the layer recorded.
+func (f *UnionFile) mergeReaddirs(lofi, basefi []os.FileInfo, whiteouts map[string]bool) []os.FileInfo {
+	var files []os.FileInfo
+	seen := map[string]bool{}
+	for _, fi := range lofi {
+		if whiteouts[fi.Name()] {
+			continue
+		}
+		seen[fi.Name()] = true
+		files = append(files, fi)
+	}
+	for _, fi := range basefi {
+		if seen[fi.Name()] || whiteouts[fi.Name()] {
+			continue
+		}
+		files = append(files, fi)
+	}
+	return files
+}
+
+func (f *UnionFile) Readdir(c int) (ofi []os.FileInfo, err error) {
+	if !f.merged {
+		var lofi []os.FileInfo
+		if f.layer != nil {
+			lofi, _ = f.layer.Readdir(-1)
+		}
+		var basefi []os.FileInfo
+		if f.base != nil {
+			basefi, _ = f.base.Readdir(-1)
+		}
+		f.files = f.mergeReaddirs(lofi, basefi, f.whiteouts)
+		f.merged = true
+	}
+	files := f.files[f.off:]
+	if c <= 0 {
+		f.off = len(f.files)
+		return files, nil
+	}
+	if len(files) == 0 {
+		return nil, io.EOF
+	}
+	if c < len(files) {
+		files = files[:c]
+	}
+	f.off += len(files)
+	return files, nil
+}
+
+func (f *UnionFile) Readdirnames(c int) ([]string, error) {
+	rfi, err := f.Readdir(c)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, fi := range rfi {
+		names = append(names, filepath.Base(fi.Name()))
+	}
+	return names, nil
+}
@@ -0,0 +1,143 @@
+package afero
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Op describes the kind of change reported by an Event. An Event from
+// MemMapFs reports exactly one Op; an Event from OsFs is translated from
+// fsnotify and can carry several. The type is a bitmask (matching the
+// shape of fsnotify.Op) so callers can test membership with
+// ev.Op&OpWrite != 0.
+type Op uint32
+
+const (
+	OpCreate Op = 1 << iota
+	OpWrite
+	OpRemove
+	OpRename
+	OpChmod
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpCreate:
+		return "CREATE"
+	case OpWrite:
+		return "WRITE"
+	case OpRemove:
+		return "REMOVE"
+	case OpRename:
+		return "RENAME"
+	case OpChmod:
+		return "CHMOD"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Event represents a single change to a watched path.
+type Event struct {
+	Name string
+	Op   Op
+}
+
+// watcherEventBuffer bounds how many unread Events a Watcher can hold
+// before send starts applying backpressure to the caller that triggered
+// the event.
+const watcherEventBuffer = 128
+
+// watcherSendTimeout bounds how long send waits for a slow consumer to
+// make room in the buffer before giving up and dropping the event. It
+// exists only to guarantee a stuck or abandoned Watcher can't wedge a
+// filesystem operation forever; a Watcher whose Events channel is read
+// continuously (the expected use) never hits it.
+const watcherSendTimeout = 2 * time.Second
+
+// Watcher delivers Events for a path and everything below it, as
+// reported by the Fs that created it (see MemMapFs.Watch), until Close
+// is called.
+type Watcher struct {
+	Events <-chan Event
+	Errors <-chan error
+
+	path      string
+	events    chan Event
+	errors    chan error
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newWatcher(path string) *Watcher {
+	w := &Watcher{
+		path:   path,
+		events: make(chan Event, watcherEventBuffer),
+		errors: make(chan error, 1),
+		closed: make(chan struct{}),
+	}
+	w.Events = w.events
+	w.Errors = w.errors
+	return w
+}
+
+// Close stops delivery of further events. It is safe to call more than
+// once and from multiple goroutines.
+func (w *Watcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.closed)
+	})
+}
+
+func (w *Watcher) isClosed() bool {
+	select {
+	case <-w.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (w *Watcher) matches(name string) bool {
+	return name == w.path || strings.HasPrefix(name, w.path+FilePathSeparator)
+}
+
+// send delivers ev, applying backpressure to the caller for up to
+// watcherSendTimeout if the Watcher's buffer is full. If the buffer is
+// still full after that, the event is dropped and a DroppedEventError is
+// put on Errors, unless Errors already holds an unread error. Once the
+// Watcher has been closed, events are dropped without any report.
+func (w *Watcher) send(ev Event) {
+	if w.isClosed() {
+		return
+	}
+	select {
+	case w.events <- ev:
+		return
+	default:
+	}
+
+	timer := time.NewTimer(watcherSendTimeout)
+	defer timer.Stop()
+	select {
+	case w.events <- ev:
+	case <-w.closed:
+	case <-timer.C:
+		select {
+		case w.errors <- &DroppedEventError{Event: ev}:
+		default:
+		}
+	}
+}
+
+// DroppedEventError is sent on a Watcher's Errors channel when its Events
+// buffer was full and an event had to be discarded rather than block the
+// filesystem operation that produced it.
+type DroppedEventError struct {
+	Event Event
+}
+
+func (e *DroppedEventError) Error() string {
+	return "afero: watcher buffer full, dropped event for " + e.Event.Name
+}
@@ -0,0 +1,83 @@
+package afero
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Watch returns a Watcher that reports filesystem change events for
+// path and everything below it at the time Watch is called, backed by
+// fsnotify. Unlike MemMapFs.Watch, directories created after Watch
+// returns are not automatically added to the underlying fsnotify
+// watch list.
+func (OsFs) Watch(path string) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || p == path {
+			return fsw.Add(p)
+		}
+		return nil
+	})
+	if err != nil {
+		fsw.Close()
+		return nil, err
+	}
+
+	w := newWatcher(normalizePath(path))
+	go pumpFsnotify(fsw, w)
+	return w, nil
+}
+
+// pumpFsnotify forwards fsw's events and errors to w, translating
+// fsnotify.Op into afero.Op, until either fsw is closed or w is.
+func pumpFsnotify(fsw *fsnotify.Watcher, w *Watcher) {
+	defer fsw.Close()
+	for {
+		select {
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return
+			}
+			w.send(Event{Name: ev.Name, Op: fromFsnotifyOp(ev.Op)})
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case w.errors <- err:
+			default:
+			}
+		case <-w.closed:
+			return
+		}
+	}
+}
+
+func fromFsnotifyOp(op fsnotify.Op) Op {
+	var out Op
+	if op&fsnotify.Create != 0 {
+		out |= OpCreate
+	}
+	if op&fsnotify.Write != 0 {
+		out |= OpWrite
+	}
+	if op&fsnotify.Remove != 0 {
+		out |= OpRemove
+	}
+	if op&fsnotify.Rename != 0 {
+		out |= OpRename
+	}
+	if op&fsnotify.Chmod != 0 {
+		out |= OpChmod
+	}
+	return out
+}
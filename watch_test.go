@@ -0,0 +1,141 @@
+package afero
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemMapFsWatchCreateWriteRemove(t *testing.T) {
+	fs := NewMemMapFs().(*MemMapFs)
+
+	if err := fs.MkdirAll("/dir", 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := fs.Watch("/dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	f, err := fs.Create("/dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Remove("/dir/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	wantOps := []Op{OpCreate, OpWrite, OpRemove}
+	for i, want := range wantOps {
+		select {
+		case ev := <-w.Events:
+			if ev.Op != want {
+				t.Fatalf("event %d = %s for %q, want %s", i, ev.Op, ev.Name, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for event %d (%s)", i, want)
+		}
+	}
+}
+
+func TestMemMapFsWatchIgnoresOtherSubtrees(t *testing.T) {
+	fs := NewMemMapFs().(*MemMapFs)
+
+	w, err := fs.Watch("/watched")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if err := fs.MkdirAll("/elsewhere", 0777); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.Create("/elsewhere/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case ev := <-w.Events:
+		t.Fatalf("unexpected event for unrelated subtree: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestMemMapFsWatchDataRace spawns concurrent create+remove pairs for a
+// set of distinct files and asserts the event stream contains exactly
+// one create and one remove per file (run with -race).
+func TestMemMapFsWatchDataRace(t *testing.T) {
+	const dir = "/race"
+	fs := NewMemMapFs().(*MemMapFs)
+	if err := fs.MkdirAll(dir, 0777); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := fs.Watch(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	const n = 200
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				fname := filepath.Join(dir, fmt.Sprintf("%d.txt", i))
+				if err := WriteFile(fs, fname, []byte(""), 0666); err != nil {
+					panic(err)
+				}
+				if err := fs.Remove(fname); err != nil {
+					panic(err)
+				}
+			}(i)
+		}
+		wg.Wait()
+	}()
+
+	creates := map[string]int{}
+	removes := map[string]int{}
+	timeout := time.After(5 * time.Second)
+	for len(creates) < n || len(removes) < n {
+		select {
+		case ev := <-w.Events:
+			switch ev.Op {
+			case OpCreate:
+				creates[ev.Name]++
+			case OpRemove:
+				removes[ev.Name]++
+			}
+		case err := <-w.Errors:
+			t.Fatal(err)
+		case <-timeout:
+			t.Fatalf("timed out: %d creates, %d removes seen", len(creates), len(removes))
+		}
+	}
+	<-done
+
+	for name, count := range creates {
+		if count != 1 {
+			t.Errorf("%s: %d create events, want 1", name, count)
+		}
+	}
+	for name, count := range removes {
+		if count != 1 {
+			t.Errorf("%s: %d remove events, want 1", name, count)
+		}
+	}
+}
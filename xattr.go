@@ -0,0 +1,41 @@
+package afero
+
+import (
+	"os"
+	"syscall"
+)
+
+// Flags accepted by XattrFs.Setxattr, mirroring the Linux setxattr(2)
+// flag values so that the Linux OsFs implementation can pass them
+// straight through; the Darwin implementation translates them to
+// Darwin's own values.
+const (
+	XATTR_CREATE  = 0x1
+	XATTR_REPLACE = 0x2
+)
+
+// XattrFs is implemented by filesystems that can store extended
+// attributes alongside a file. Callers that need it should type-assert
+// an Fs to this interface, the same way Lstater and Symlinker are used,
+// since not every Fs has a meaningful notion of xattrs.
+type XattrFs interface {
+	// Getxattr returns the value of the named extended attribute.
+	Getxattr(name, attr string) ([]byte, error)
+	// Setxattr sets the named extended attribute to data. flags is a
+	// combination of XATTR_CREATE and XATTR_REPLACE; passing both, or
+	// passing XATTR_CREATE when the attribute already exists (or
+	// XATTR_REPLACE when it does not), is an error.
+	Setxattr(name, attr string, data []byte, flags int) error
+	// Listxattr lists the names of the extended attributes set on name.
+	Listxattr(name string) ([]string, error)
+	// Removexattr removes the named extended attribute.
+	Removexattr(name, attr string) error
+}
+
+// ErrNoXattr is returned by XattrFs wrappers (e.g. BasePathFs) whose
+// underlying Fs does not implement XattrFs.
+var ErrNoXattr = syscall.ENOTSUP
+
+func noXattrFs(op, path string) error {
+	return &os.PathError{Op: op, Path: path, Err: ErrNoXattr}
+}
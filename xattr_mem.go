@@ -0,0 +1,69 @@
+package afero
+
+import (
+	"os"
+
+	"github.com/getporter/afero/mem"
+)
+
+// Getxattr implements XattrFs.
+func (m *MemMapFs) Getxattr(name, attr string) ([]byte, error) {
+	name = normalizePath(name)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, err := m.resolve(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "getxattr", Path: name, Err: os.ErrNotExist}
+	}
+	v, err := mem.GetXattr(f, attr)
+	if err != nil {
+		return nil, &os.PathError{Op: "getxattr", Path: name, Err: err}
+	}
+	return v, nil
+}
+
+// Setxattr implements XattrFs.
+func (m *MemMapFs) Setxattr(name, attr string, data []byte, flags int) error {
+	name = normalizePath(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := m.resolve(name)
+	if err != nil {
+		return &os.PathError{Op: "setxattr", Path: name, Err: os.ErrNotExist}
+	}
+	if err := mem.SetXattr(f, attr, data, flags); err != nil {
+		return &os.PathError{Op: "setxattr", Path: name, Err: err}
+	}
+	return nil
+}
+
+// Listxattr implements XattrFs.
+func (m *MemMapFs) Listxattr(name string) ([]string, error) {
+	name = normalizePath(name)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	f, err := m.resolve(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "listxattr", Path: name, Err: os.ErrNotExist}
+	}
+	return mem.ListXattr(f), nil
+}
+
+// Removexattr implements XattrFs.
+func (m *MemMapFs) Removexattr(name, attr string) error {
+	name = normalizePath(name)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f, err := m.resolve(name)
+	if err != nil {
+		return &os.PathError{Op: "removexattr", Path: name, Err: os.ErrNotExist}
+	}
+	if err := mem.RemoveXattr(f, attr); err != nil {
+		return &os.PathError{Op: "removexattr", Path: name, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+//go:build darwin
+
+package afero
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Getxattr implements XattrFs using the getxattr(2) syscall.
+func (OsFs) Getxattr(name, attr string) ([]byte, error) {
+	size, err := unix.Getxattr(name, attr, nil)
+	if err != nil {
+		return nil, &os.PathError{Op: "getxattr", Path: name, Err: err}
+	}
+	buf := make([]byte, size)
+	if size > 0 {
+		if _, err := unix.Getxattr(name, attr, buf); err != nil {
+			return nil, &os.PathError{Op: "getxattr", Path: name, Err: err}
+		}
+	}
+	return buf, nil
+}
+
+// Setxattr implements XattrFs using the setxattr(2) syscall. Darwin's
+// setxattr(2) takes a position argument in addition to flags; afero's
+// XattrFs has no use for it, so it is always 0. Darwin's XATTR_CREATE
+// and XATTR_REPLACE values differ from afero's, so flags is translated.
+func (OsFs) Setxattr(name, attr string, data []byte, flags int) error {
+	var dflags int
+	if flags&XATTR_CREATE != 0 {
+		dflags |= unix.XATTR_CREATE
+	}
+	if flags&XATTR_REPLACE != 0 {
+		dflags |= unix.XATTR_REPLACE
+	}
+	if err := unix.Setxattr(name, attr, data, dflags); err != nil {
+		return &os.PathError{Op: "setxattr", Path: name, Err: err}
+	}
+	return nil
+}
+
+// Listxattr implements XattrFs using the listxattr(2) syscall.
+func (OsFs) Listxattr(name string) ([]string, error) {
+	size, err := unix.Listxattr(name, nil)
+	if err != nil {
+		return nil, &os.PathError{Op: "listxattr", Path: name, Err: err}
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, size)
+	if _, err := unix.Listxattr(name, buf); err != nil {
+		return nil, &os.PathError{Op: "listxattr", Path: name, Err: err}
+	}
+	return splitXattrNames(buf), nil
+}
+
+// Removexattr implements XattrFs using the removexattr(2) syscall.
+func (OsFs) Removexattr(name, attr string) error {
+	if err := unix.Removexattr(name, attr); err != nil {
+		return &os.PathError{Op: "removexattr", Path: name, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build linux || darwin
+
+package afero
+
+// splitXattrNames splits the NUL-separated attribute name list returned
+// by listxattr(2) into individual strings.
+func splitXattrNames(buf []byte) []string {
+	var names []string
+	start := 0
+	for i, b := range buf {
+		if b == 0 {
+			if i > start {
+				names = append(names, string(buf[start:i]))
+			}
+			start = i + 1
+		}
+	}
+	return names
+}
@@ -0,0 +1,157 @@
+package afero
+
+import (
+	"os"
+	"syscall"
+)
+
+// Getxattr delegates to the source Fs if it implements XattrFs,
+// translating the path the same way Stat does.
+func (b *BasePathFs) Getxattr(name, attr string) ([]byte, error) {
+	xfs, ok := b.source.(XattrFs)
+	if !ok {
+		return nil, noXattrFs("getxattr", name)
+	}
+	name, err := b.RealPath(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "getxattr", Path: name, Err: err}
+	}
+	return xfs.Getxattr(name, attr)
+}
+
+func (b *BasePathFs) Setxattr(name, attr string, data []byte, flags int) error {
+	xfs, ok := b.source.(XattrFs)
+	if !ok {
+		return noXattrFs("setxattr", name)
+	}
+	name, err := b.RealPath(name)
+	if err != nil {
+		return &os.PathError{Op: "setxattr", Path: name, Err: err}
+	}
+	return xfs.Setxattr(name, attr, data, flags)
+}
+
+func (b *BasePathFs) Listxattr(name string) ([]string, error) {
+	xfs, ok := b.source.(XattrFs)
+	if !ok {
+		return nil, noXattrFs("listxattr", name)
+	}
+	name, err := b.RealPath(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "listxattr", Path: name, Err: err}
+	}
+	return xfs.Listxattr(name)
+}
+
+func (b *BasePathFs) Removexattr(name, attr string) error {
+	xfs, ok := b.source.(XattrFs)
+	if !ok {
+		return noXattrFs("removexattr", name)
+	}
+	name, err := b.RealPath(name)
+	if err != nil {
+		return &os.PathError{Op: "removexattr", Path: name, Err: err}
+	}
+	return xfs.Removexattr(name, attr)
+}
+
+// Getxattr reads from the layer if it has the file, otherwise the base.
+func (u *CopyOnWriteFs) Getxattr(name, attr string) ([]byte, error) {
+	if u.isWhiteout(name) {
+		return nil, &os.PathError{Op: "getxattr", Path: name, Err: os.ErrNotExist}
+	}
+	if xfs, ok := u.layer.(XattrFs); ok {
+		if _, err := u.layer.Stat(name); err == nil {
+			return xfs.Getxattr(name, attr)
+		}
+	}
+	xfs, ok := u.base.(XattrFs)
+	if !ok {
+		return nil, noXattrFs("getxattr", name)
+	}
+	return xfs.Getxattr(name, attr)
+}
+
+// Setxattr always writes to the layer, copying a base-only file up
+// first so the attribute has a layer inode to live on.
+func (u *CopyOnWriteFs) Setxattr(name, attr string, data []byte, flags int) error {
+	xfs, ok := u.layer.(XattrFs)
+	if !ok {
+		return noXattrFs("setxattr", name)
+	}
+	if u.isWhiteout(name) {
+		return &os.PathError{Op: "setxattr", Path: name, Err: os.ErrNotExist}
+	}
+	b, err := u.isBaseFile(name)
+	if err != nil {
+		return err
+	}
+	if b {
+		if err := u.copyToLayer(name); err != nil {
+			return err
+		}
+	}
+	return xfs.Setxattr(name, attr, data, flags)
+}
+
+func (u *CopyOnWriteFs) Listxattr(name string) ([]string, error) {
+	if u.isWhiteout(name) {
+		return nil, &os.PathError{Op: "listxattr", Path: name, Err: os.ErrNotExist}
+	}
+	if xfs, ok := u.layer.(XattrFs); ok {
+		if _, err := u.layer.Stat(name); err == nil {
+			return xfs.Listxattr(name)
+		}
+	}
+	xfs, ok := u.base.(XattrFs)
+	if !ok {
+		return nil, noXattrFs("listxattr", name)
+	}
+	return xfs.Listxattr(name)
+}
+
+func (u *CopyOnWriteFs) Removexattr(name, attr string) error {
+	xfs, ok := u.layer.(XattrFs)
+	if !ok {
+		return noXattrFs("removexattr", name)
+	}
+	if u.isWhiteout(name) {
+		return &os.PathError{Op: "removexattr", Path: name, Err: os.ErrNotExist}
+	}
+	b, err := u.isBaseFile(name)
+	if err != nil {
+		return err
+	}
+	if b {
+		if err := u.copyToLayer(name); err != nil {
+			return err
+		}
+	}
+	return xfs.Removexattr(name, attr)
+}
+
+// Getxattr and Listxattr pass through to the source; Setxattr and
+// Removexattr always fail since they mutate the filesystem.
+func (r *ReadOnlyFs) Getxattr(name, attr string) ([]byte, error) {
+	xfs, ok := r.source.(XattrFs)
+	if !ok {
+		return nil, noXattrFs("getxattr", name)
+	}
+	return xfs.Getxattr(name, attr)
+}
+
+func (r *ReadOnlyFs) Setxattr(name, attr string, data []byte, flags int) error {
+	return &os.PathError{Op: "setxattr", Path: name, Err: syscall.EROFS}
+}
+
+func (r *ReadOnlyFs) Listxattr(name string) ([]string, error) {
+	xfs, ok := r.source.(XattrFs)
+	if !ok {
+		return nil, noXattrFs("listxattr", name)
+	}
+	return xfs.Listxattr(name)
+}
+
+func (r *ReadOnlyFs) Removexattr(name, attr string) error {
+	return &os.PathError{Op: "removexattr", Path: name, Err: syscall.EROFS}
+}
@@ -0,0 +1,67 @@
+package afero
+
+import (
+	"testing"
+)
+
+// TestXattrPathErrors mirrors TestPathErrors: every XattrFs operation on
+// a file that does not exist must fail with a bare *os.PathError.
+func TestXattrPathErrors(t *testing.T) {
+	fs := NewMemMapFs()
+	path := "some/missing/file"
+
+	var xfs XattrFs = fs.(XattrFs)
+
+	_, err := xfs.Getxattr(path, "user.test")
+	checkPathError(t, err, "Getxattr")
+
+	err = xfs.Setxattr(path, "user.test", []byte("v"), 0)
+	checkPathError(t, err, "Setxattr")
+
+	_, err = xfs.Listxattr(path)
+	checkPathError(t, err, "Listxattr")
+
+	err = xfs.Removexattr(path, "user.test")
+	checkPathError(t, err, "Removexattr")
+}
+
+func TestXattrRoundTrip(t *testing.T) {
+	fs := NewMemMapFs()
+	xfs := fs.(XattrFs)
+
+	if _, err := fs.Create("file.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := xfs.Setxattr("file.txt", "user.test", []byte("hello"), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := xfs.Getxattr("file.txt", "user.test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v) != "hello" {
+		t.Errorf("Getxattr = %q, want %q", v, "hello")
+	}
+
+	names, err := xfs.Listxattr("file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 1 || names[0] != "user.test" {
+		t.Errorf("Listxattr = %v, want [user.test]", names)
+	}
+
+	if err := xfs.Setxattr("file.txt", "user.test", nil, XATTR_CREATE); err == nil {
+		t.Error("Setxattr with XATTR_CREATE on an existing attribute should fail")
+	}
+
+	if err := xfs.Removexattr("file.txt", "user.test"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := xfs.Getxattr("file.txt", "user.test"); err == nil {
+		t.Error("Getxattr after Removexattr should fail")
+	}
+}